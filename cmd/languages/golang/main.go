@@ -0,0 +1,231 @@
+package golang
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"kiber-io/lenv/common"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ver "github.com/hashicorp/go-version"
+)
+
+const defaultBaseURL = "https://go.dev/dl"
+
+type golang struct{}
+
+func init() {
+	common.RegisterLanguage(golang{})
+}
+
+func (golang) Name() string {
+	return "go"
+}
+
+func (golang) Title() string {
+	return "Go"
+}
+
+func (golang) Aliases() []string {
+	return []string{"golang"}
+}
+
+func (golang) Source() common.ReleaseSource {
+	return dlSource{BaseURL: common.MirrorURL("go", defaultBaseURL)}
+}
+
+func (golang) BinDirs(version common.Version) []string {
+	return []string{filepath.Join(version.Path, "bin")}
+}
+
+func (golang) PostInstall(version common.Version) error {
+	return nil
+}
+
+func (golang) SmokeTest() (string, []string) {
+	return "go", []string{"version"}
+}
+
+func (golang) Env(version common.Version) map[string]string {
+	return map[string]string{"GOROOT": version.Path}
+}
+
+func (golang) VersionLine(version common.Version) string {
+	return common.MinorLine(version)
+}
+
+// DetectVersion reads the toolchain and go directives of go.mod in dir. Both
+// are minimums, as for the go command: each selects the newest installed
+// patch release of its Go line that satisfies it, or else the newest
+// installed version that satisfies it. The toolchain directive is tried
+// first; if no installed version satisfies either, go.mod is ignored and the
+// global version applies.
+func (golang) DetectVersion(dir string, installed []common.Version) (string, string) {
+	path := filepath.Join(dir, "go.mod")
+	file, err := os.Open(path)
+	if err != nil {
+		return "", ""
+	}
+	defer file.Close()
+
+	goVersion := ""
+	toolchain := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(strings.SplitN(scanner.Text(), "//", 2)[0])
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "go":
+			goVersion = fields[1]
+		case "toolchain":
+			if fields[1] != "default" {
+				toolchain = strings.TrimPrefix(fields[1], "go")
+			}
+		}
+	}
+	for _, minimum := range []string{toolchain, goVersion} {
+		if minimum == "" {
+			continue
+		}
+		if name := matchGoDirective(minimum, installed); name != "" {
+			return name, path
+		}
+	}
+	return "", ""
+}
+
+func matchGoDirective(goVersion string, installed []common.Version) string {
+	minimum, err := ver.NewVersion(goVersion)
+	if err != nil {
+		return goVersion
+	}
+	segments := minimum.Segments()
+	var sameLine, newest *ver.Version
+	sameLineName, newestName := "", ""
+	for _, candidate := range installed {
+		v, err := ver.NewVersion(candidate.Version)
+		if err != nil || v.LessThan(minimum) {
+			continue
+		}
+		if newest == nil || v.GreaterThan(newest) {
+			newest = v
+			newestName = candidate.Name()
+		}
+		s := v.Segments()
+		if s[0] != segments[0] || s[1] != segments[1] {
+			continue
+		}
+		if sameLine == nil || v.GreaterThan(sameLine) {
+			sameLine = v
+			sameLineName = candidate.Name()
+		}
+	}
+	if sameLine != nil {
+		return sameLineName
+	}
+	return newestName
+}
+
+// dlSource reads releases from the Go download page in JSON mode.
+type dlSource struct {
+	BaseURL string
+}
+
+type release struct {
+	Version string `json:"version"`
+	Files   []struct {
+		Filename string `json:"filename"`
+		OS       string `json:"os"`
+		Arch     string `json:"arch"`
+		Kind     string `json:"kind"`
+		SHA256   string `json:"sha256"`
+	} `json:"files"`
+}
+
+func archiveName(version string, platform string, arch string) string {
+	suffix := ".tar.gz"
+	if platform == "windows" {
+		suffix = ".zip"
+	}
+	return fmt.Sprintf("go%s.%s-%s%s", version, platform, arch, suffix)
+}
+
+func (s dlSource) fetchReleases() ([]release, error) {
+	resp, err := common.HTTPGet(s.BaseURL + "/?mode=json&include=all")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JSON: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	var releases []release
+	err = json.Unmarshal(body, &releases)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON: %v", err)
+	}
+	return releases, nil
+}
+
+func (s dlSource) FetchVersions(platform string, arch string) ([]common.Version, error) {
+	if platform == "android" {
+		platform = "linux"
+	}
+	releases, err := s.fetchReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []common.Version{}
+	for _, r := range releases {
+		for _, f := range r.Files {
+			if f.Kind == "archive" && f.OS == platform && f.Arch == arch {
+				versions = append(versions, common.Version{Version: strings.TrimPrefix(r.Version, "go")})
+				break
+			}
+		}
+	}
+	return versions, nil
+}
+
+func (s dlSource) DownloadURL(version common.Version, platform string, arch string) (string, error) {
+	if platform == "android" {
+		platform = "linux"
+	}
+	if arch != "amd64" && arch != "arm64" {
+		return "", fmt.Errorf("unknown operating system and architecture: %s/%s", platform, arch)
+	}
+	return fmt.Sprintf("%s/%s", s.BaseURL, archiveName(version.Version, platform, arch)), nil
+}
+
+// Checksum reads the digest that the release feed lists for every file.
+func (s dlSource) Checksum(version common.Version, platform string, arch string) (common.Checksum, error) {
+	if platform == "android" {
+		platform = "linux"
+	}
+	releases, err := s.fetchReleases()
+	if err != nil {
+		return common.Checksum{}, err
+	}
+	name := archiveName(version.Version, platform, arch)
+	for _, r := range releases {
+		for _, f := range r.Files {
+			if f.Filename == name && f.SHA256 != "" {
+				return common.Checksum{Algorithm: "sha256", Value: f.SHA256}, nil
+			}
+		}
+	}
+	return common.Checksum{}, fmt.Errorf("release feed has no checksum for %s", name)
+}
@@ -0,0 +1,135 @@
+package gradle
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"kiber-io/lenv/common"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	ver "github.com/hashicorp/go-version"
+)
+
+const defaultBaseURL = "https://services.gradle.org"
+
+type gradle struct{}
+
+func init() {
+	common.RegisterLanguage(gradle{})
+}
+
+func (gradle) Name() string {
+	return "gradle"
+}
+
+func (gradle) Title() string {
+	return "Gradle"
+}
+
+func (gradle) Aliases() []string {
+	return []string{"gr"}
+}
+
+func (gradle) Source() common.ReleaseSource {
+	return servicesSource{BaseURL: common.MirrorURL("gradle", defaultBaseURL)}
+}
+
+func (gradle) BinDirs(version common.Version) []string {
+	return []string{filepath.Join(version.Path, "bin")}
+}
+
+func (gradle) PostInstall(version common.Version) error {
+	return nil
+}
+
+func (gradle) Env(version common.Version) map[string]string {
+	return map[string]string{"GRADLE_HOME": version.Path}
+}
+
+// RequiredJDK follows the Java requirements of the Gradle release history.
+func (gradle) RequiredJDK(version common.Version) string {
+	v, err := ver.NewVersion(version.Version)
+	if err != nil {
+		return ""
+	}
+	major := v.Segments()[0]
+	switch {
+	case major >= 9:
+		return "17"
+	case major >= 5:
+		return "8"
+	case major >= 3:
+		return "7"
+	default:
+		return "6"
+	}
+}
+
+// servicesSource reads Gradle distributions from services.gradle.org.
+type servicesSource struct {
+	BaseURL string
+}
+
+type release struct {
+	Version        string `json:"version"`
+	Snapshot       bool   `json:"snapshot"`
+	Nightly        bool   `json:"nightly"`
+	ReleaseNightly bool   `json:"releaseNightly"`
+	Broken         bool   `json:"broken"`
+	RcFor          string `json:"rcFor"`
+	MilestoneFor   string `json:"milestoneFor"`
+}
+
+func (s servicesSource) FetchVersions(platform string, arch string) ([]common.Version, error) {
+	resp, err := common.HTTPGet(s.BaseURL + "/versions/all")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JSON: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	var releases []release
+	err = json.Unmarshal(body, &releases)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON: %v", err)
+	}
+
+	versions := []common.Version{}
+	for _, r := range releases {
+		if r.Snapshot || r.Nightly || r.ReleaseNightly || r.Broken || r.RcFor != "" || r.MilestoneFor != "" {
+			continue
+		}
+		versions = append(versions, common.Version{Version: r.Version})
+	}
+	return versions, nil
+}
+
+func (s servicesSource) DownloadURL(version common.Version, platform string, arch string) (string, error) {
+	return fmt.Sprintf("%s/distributions/gradle-%s-bin.zip", s.BaseURL, version.Version), nil
+}
+
+// Checksum reads the SHA-256 file that is published next to every distribution.
+func (s servicesSource) Checksum(version common.Version, platform string, arch string) (common.Checksum, error) {
+	url, err := s.DownloadURL(version, platform, arch)
+	if err != nil {
+		return common.Checksum{}, err
+	}
+	data, err := common.FetchText(url + ".sha256")
+	if err != nil {
+		return common.Checksum{}, fmt.Errorf("failed to fetch checksum: %v", err)
+	}
+	fields := strings.Fields(data)
+	if len(fields) == 0 {
+		return common.Checksum{}, fmt.Errorf("empty checksum file for %s", url)
+	}
+	return common.Checksum{Algorithm: "sha256", Value: fields[0]}, nil
+}
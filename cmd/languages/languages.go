@@ -0,0 +1,984 @@
+package languages
+
+import (
+	"fmt"
+	"kiber-io/lenv/common"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCommand builds the command family of a language.
+func NewCommand(language common.Language) *cobra.Command {
+	var showAll bool
+	var filter listFilter
+	var unsetLocal bool
+	var unsetShell bool
+	var requiresJDK string
+	var fromFile string
+	var fromURL string
+	var installName string
+
+	var languageCmd = &cobra.Command{
+		Use:     language.Name(),
+		Aliases: language.Aliases(),
+		Short:   fmt.Sprintf("Manage %s versions", language.Title()),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				_ = cmd.Help()
+				return
+			}
+		},
+	}
+	var installCmd = &cobra.Command{
+		Use:     "install [version]",
+		Short:   fmt.Sprintf("Install specific %s version", language.Title()),
+		Aliases: []string{"i"},
+		Args:    cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if common.SkipSignatureVerification {
+				fmt.Fprintln(os.Stderr, "WARNING: --insecure-skip-verify is set, release signatures will not be checked")
+			}
+			if fromFile != "" || fromURL != "" {
+				if fromFile != "" && fromURL != "" {
+					log.Fatal("--from-file and --from-url can't be used together")
+				}
+				name := installName
+				if name == "" && len(args) == 1 {
+					name = args[0]
+				}
+				if name == "" {
+					log.Fatal("--name is required with --from-file and --from-url")
+				}
+				installArchive(language, fromFile, fromURL, name, requiresJDK)
+				return
+			}
+			if len(args) != 1 {
+				_ = cmd.Help()
+				return
+			}
+			install(language, args[0], requiresJDK)
+		},
+	}
+	var uninstallCmd = &cobra.Command{
+		Use:     "uninstall [version]",
+		Short:   fmt.Sprintf("Uninstall specific %s version", language.Title()),
+		Aliases: []string{"u"},
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			uninstall(language, args[0])
+		},
+	}
+	var listCmd = &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   fmt.Sprintf("List installed or available %s versions", language.Title()),
+		Run: func(cmd *cobra.Command, args []string) {
+			if showAll {
+				listAvailable(language, filter)
+			} else {
+				listInstalled(language, filter)
+			}
+		},
+	}
+	var globalCmd = &cobra.Command{
+		Use:     "global",
+		Aliases: []string{"g"},
+		Short:   fmt.Sprintf("Set global %s version", language.Title()),
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			setGlobal(language, args[0])
+		},
+	}
+	var localCmd = &cobra.Command{
+		Use:     "local [version]",
+		Aliases: []string{"l"},
+		Short:   fmt.Sprintf("Set or show the %s version of the current project", language.Title()),
+		Args:    cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if unsetLocal {
+				unsetLocalVersion(language)
+			} else if len(args) == 0 {
+				showLocal(language)
+			} else {
+				setLocal(language, args[0])
+			}
+		},
+	}
+	var shellCmd = &cobra.Command{
+		Use:   "shell [version]",
+		Short: fmt.Sprintf("Set or show the %s version of the current shell", language.Title()),
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if unsetShell {
+				setShell(language, "")
+			} else if len(args) == 0 {
+				showShell(language)
+			} else {
+				setShell(language, args[0])
+			}
+		},
+	}
+	var currentCmd = &cobra.Command{
+		Use:     "current",
+		Aliases: []string{"c"},
+		Short:   fmt.Sprintf("Show the active %s version", language.Title()),
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			showCurrent(language)
+		},
+	}
+	var infoCmd = &cobra.Command{
+		Use:   "info [version]",
+		Short: fmt.Sprintf("Show details of a %s version, the active one by default", language.Title()),
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			spec := ""
+			if len(args) == 1 {
+				spec = args[0]
+			}
+			showInfo(language, spec)
+		},
+	}
+	var rehashCmd = &cobra.Command{
+		Use:   "rehash",
+		Short: fmt.Sprintf("Rebuild shims for installed %s executables", language.Title()),
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			rehash(language)
+		},
+	}
+	var execCmd = &cobra.Command{
+		Use:   "exec <command> [args...]",
+		Short: fmt.Sprintf("Run an executable of the active %s version", language.Title()),
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			execCommand(language, args)
+		},
+		DisableFlagsInUseLine: true,
+		DisableFlagParsing:    true,
+	}
+	listCmd.Flags().BoolVarP(&showAll, "all", "a", false, "Show all available versions")
+	listCmd.Flags().StringVar(&filter.vendor, "vendor", "", "Show only versions of the vendor")
+	listCmd.Flags().StringVar(&filter.line, "major", "", "Show only versions of the release line, e.g. 17 or 3.12")
+	listCmd.Flags().BoolVar(&filter.installedOnly, "installed-only", false, "Show only installed versions with --all")
+	installCmd.Flags().StringVar(&fromFile, "from-file", "", "Install from a local archive instead of the release source")
+	installCmd.Flags().StringVar(&fromURL, "from-url", "", "Install from the archive at a URL instead of the release source")
+	installCmd.Flags().StringVar(&installName, "name", "", "Version name for --from-file and --from-url, e.g. 21-internal")
+	installCmd.Flags().BoolVar(&common.SkipSignatureVerification, "insecure-skip-verify", false, "Do not verify the signatures of checksum manifests")
+	localCmd.Flags().BoolVar(&unsetLocal, "unset", false, "Remove the project version")
+	shellCmd.Flags().BoolVar(&unsetShell, "unset", false, "Remove the shell version")
+	if _, ok := language.(common.JDKRequirement); ok {
+		installCmd.Flags().StringVar(&requiresJDK, "requires-jdk", "", "Minimum Java major version needed by this install (detected by default)")
+	}
+
+	languageCmd.AddCommand(installCmd)
+	languageCmd.AddCommand(uninstallCmd)
+	languageCmd.AddCommand(listCmd)
+	languageCmd.AddCommand(globalCmd)
+	languageCmd.AddCommand(localCmd)
+	languageCmd.AddCommand(shellCmd)
+	languageCmd.AddCommand(currentCmd)
+	languageCmd.AddCommand(infoCmd)
+	languageCmd.AddCommand(rehashCmd)
+	languageCmd.AddCommand(execCmd)
+
+	languageCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		if cmd != installCmd && cmd != uninstallCmd {
+			lockLanguage(language, exclusiveCommands[cmd.Name()])
+		}
+		common.LoadConfig(language.Name())
+	}
+	languageCmd.PersistentPostRun = func(cmd *cobra.Command, args []string) {
+		unlockLanguage()
+	}
+	return languageCmd
+}
+
+// exclusiveCommands change the versions of a language and must not run
+// concurrently with any other command for the same language. install and
+// uninstall are missing: they lock only while they change the versions, so
+// that a long download or a confirmation prompt doesn't hold up the shims.
+var exclusiveCommands = map[string]bool{
+	"global": true,
+	"rehash": true,
+}
+
+var languageLock *common.Lock
+
+func lockLanguage(language common.Language, exclusive bool) {
+	lock, err := common.LockLanguage(language.Name(), exclusive)
+	if err != nil {
+		log.Fatalf("Failed to lock %s versions: %v", language.Title(), err)
+	}
+	languageLock = lock
+}
+
+func unlockLanguage() {
+	err := languageLock.Unlock()
+	if err != nil {
+		log.Fatalf("Failed to unlock: %v", err)
+	}
+	languageLock = nil
+}
+
+// RehashLocked is like Rehash, but takes the lock of the language first.
+func RehashLocked(language common.Language) {
+	lockLanguage(language, true)
+	Rehash(language)
+	unlockLanguage()
+}
+
+func install(language common.Language, spec string, requiresJDK string) {
+	if installed := common.FindVersionByName(common.Config.InstalledVersions, spec); installed != nil {
+		alreadyInstalled(language, *installed)
+		return
+	}
+	target := resolveAvailable(language, spec)
+	version := target.Name()
+	if installed := common.FindVersionByName(common.Config.InstalledVersions, version); installed != nil {
+		alreadyInstalled(language, *installed)
+		return
+	}
+	url, err := language.Source().DownloadURL(target, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		log.Fatalf("Failed to download file: %v", err)
+	}
+	checksum, err := language.Source().Checksum(target, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		log.Fatalf("Failed to get checksum of %s version %s: %v", language.Title(), version, err)
+	}
+	useCache := common.CacheEnabled()
+	filePath, cached := "", false
+	if useCache {
+		filePath, cached = common.FindCached(checksum)
+	}
+	if cached {
+		common.Statusln("Using cached archive...")
+	} else {
+		common.Statusln("Downloading...")
+		filePath, err = common.DownloadFile(url)
+		if err != nil {
+			log.Fatalf("Failed to download file: %v", err)
+		}
+	}
+	common.Statusln("Verifying...")
+	checksum, err = common.VerifyChecksum(filePath, checksum)
+	if err != nil {
+		if cached {
+			common.RemoveCached(common.CacheEntry{Path: filePath})
+		} else {
+			os.Remove(filePath)
+		}
+		log.Fatalf("Failed to verify %s version %s: %v", language.Title(), version, err)
+	}
+	if useCache && !cached {
+		filePath, err = common.AddToCache(filePath, checksum, common.ArchiveFileName(url))
+		if err != nil {
+			log.Fatalf("Failed to cache %s version %s: %v", language.Title(), version, err)
+		}
+	}
+	unpack(language, target, filePath, checksum, requiresJDK)
+	if !useCache {
+		os.Remove(filePath)
+	}
+	if err := common.PruneCache(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to clean up the download cache: %v\n", err)
+	}
+}
+
+// installArchive installs a build that is not published by the release
+// source, from a local archive or from a URL, under the given name.
+func installArchive(language common.Language, file string, url string, name string, requiresJDK string) {
+	if installed := common.FindVersionByName(common.Config.InstalledVersions, name); installed != nil {
+		alreadyInstalled(language, *installed)
+		return
+	}
+	version, vendor := common.ParseVersionName(name)
+	target := common.Version{Version: version, Vendor: vendor}
+	if url != "" {
+		common.Statusln("Downloading...")
+		var err error
+		file, err = common.DownloadFile(url)
+		if err != nil {
+			log.Fatalf("Failed to download file: %v", err)
+		}
+		defer os.Remove(file)
+	}
+	checksum, err := common.FileChecksum(file, "sha256")
+	if err != nil {
+		log.Fatalf("Failed to read archive: %v", err)
+	}
+	unpack(language, target, file, checksum, requiresJDK)
+}
+
+func alreadyInstalled(language common.Language, version common.Version) {
+	if common.IsJSON() {
+		common.PrintJSON(common.DescribeVersion(version))
+		return
+	}
+	fmt.Printf("%s version %s is already installed\n", language.Title(), version.Name())
+}
+
+// unpack extracts a verified archive and finishes the installation of
+// target. Everything happens in a staging directory next to the versions,
+// which is renamed into place only when all steps succeeded, so a failed or
+// interrupted install leaves nothing behind. The language is locked
+// exclusively only while the version is moved into place and finalized.
+func unpack(language common.Language, target common.Version, filePath string, checksum common.Checksum, requiresJDK string) {
+	version := target.Name()
+	finalPath := common.VersionPath(target)
+	stagingPath, err := os.MkdirTemp(common.Config.VersionsDir, ".install-"+version+"-")
+	if err != nil {
+		log.Fatalf("Failed to create staging directory: %v", err)
+	}
+	cleanup := cleanupOnInterrupt(stagingPath)
+	fail := func(format string, args ...any) {
+		cleanup.stop()
+		os.RemoveAll(stagingPath)
+		log.Fatalf(format, args...)
+	}
+	// MkdirTemp creates the directory for the owner only, but it becomes
+	// the version directory.
+	err = os.Chmod(stagingPath, 0755)
+	if err != nil {
+		fail("Failed to create staging directory: %v", err)
+	}
+	target.Path = stagingPath
+	common.Statusln("Extracting...")
+	err = common.Extract(filePath, target.Path, true)
+	if err != nil {
+		fail("Failed to extract %s version %s: %v", language.Title(), version, err)
+	}
+	err = language.PostInstall(target)
+	if err != nil {
+		fail("Failed to install %s version %s: %v", language.Title(), version, err)
+	}
+	err = smokeTest(language, target)
+	if err != nil {
+		fail("%s version %s does not work: %v", language.Title(), version, err)
+	}
+	if requirement, ok := language.(common.JDKRequirement); ok && requiresJDK == "" {
+		requiresJDK = requirement.RequiredJDK(target)
+	}
+	target.RequiresJDK = requiresJDK
+	info := common.InstallInfo{RequiresJDK: requiresJDK, Checksum: checksum.String()}
+	finalizer, finalize := language.(common.InstallFinalizer)
+	if finalize {
+		err = common.MarkIncomplete(stagingPath)
+	} else {
+		err = common.WriteInstallInfo(target, info)
+	}
+	if err != nil {
+		fail("Failed to write install info: %v", err)
+	}
+	lockLanguage(language, true)
+	defer unlockLanguage()
+	// Another process may have installed the version meanwhile.
+	common.LoadConfig(language.Name())
+	if installed := common.FindVersionByName(common.Config.InstalledVersions, version); installed != nil {
+		cleanup.stop()
+		os.RemoveAll(stagingPath)
+		alreadyInstalled(language, *installed)
+		return
+	}
+	if common.IsIncomplete(finalPath) {
+		// Left behind by an install that crashed while it was finalized.
+		err = os.RemoveAll(finalPath)
+		if err != nil {
+			fail("Failed to remove incomplete %s version %s: %v", language.Title(), version, err)
+		}
+	}
+	err = os.Rename(stagingPath, finalPath)
+	if err != nil {
+		fail("Failed to move %s version %s into place: %v", language.Title(), version, err)
+	}
+	target.Path = finalPath
+	if finalize {
+		// Install info is written last, so that the version is only taken
+		// for installed once it was finalized.
+		cleanup.setPath(finalPath)
+		err = finalizer.FinalizeInstall(target)
+		if err == nil {
+			err = common.WriteInstallInfo(target, info)
+		}
+		if err == nil {
+			err = common.MarkComplete(finalPath)
+		}
+		if err != nil {
+			cleanup.stop()
+			os.RemoveAll(finalPath)
+			log.Fatalf("Failed to install %s version %s: %v", language.Title(), version, err)
+		}
+	}
+	cleanup.stop()
+	Rehash(language)
+	if common.IsJSON() {
+		common.PrintJSON(common.DescribeVersion(target))
+	} else {
+		fmt.Printf("%s version %s installed\n", language.Title(), version)
+	}
+	warnRequirements(language, target)
+}
+
+// smokeTest checks that a staged version has executables and, if the
+// language has a SmokeTester, that its test command succeeds.
+func smokeTest(language common.Language, version common.Version) error {
+	dirs := language.BinDirs(version)
+	found := false
+	for _, dir := range dirs {
+		executables, err := common.FindExecutables(dir)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", dir, err)
+		}
+		if len(executables) > 0 {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("no executables found in %s", strings.Join(dirs, ", "))
+	}
+	tester, ok := language.(common.SmokeTester)
+	if !ok {
+		return nil
+	}
+	name, args := tester.SmokeTest()
+	path := common.FindExecutable(dirs, name)
+	if path == "" {
+		return fmt.Errorf("%s not found", name)
+	}
+	env := common.PrependPath(os.Environ(), dirs)
+	for name, value := range language.Env(version) {
+		env = common.SetEnv(env, name, value)
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Env = env
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %v\n%s", name, strings.Join(args, " "), err, output)
+	}
+	return nil
+}
+
+// interruptCleanup removes a directory if the process is interrupted.
+type interruptCleanup struct {
+	mu      sync.Mutex
+	path    string
+	signals chan os.Signal
+}
+
+func cleanupOnInterrupt(path string) *interruptCleanup {
+	signals := make(chan os.Signal, 1)
+	c := &interruptCleanup{path: path, signals: signals}
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		if _, ok := <-signals; !ok {
+			return
+		}
+		c.mu.Lock()
+		os.RemoveAll(c.path)
+		fmt.Fprintln(os.Stderr, "Install interrupted, removed", c.path)
+		os.Exit(130)
+	}()
+	return c
+}
+
+func (c *interruptCleanup) setPath(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.path = path
+}
+
+func (c *interruptCleanup) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.signals != nil {
+		signal.Stop(c.signals)
+		close(c.signals)
+		c.signals = nil
+	}
+}
+
+func uninstall(language common.Language, spec string) {
+	installed := resolveInstalled(spec)
+	if installed == nil {
+		fmt.Printf("%s version %s is not installed\n", language.Title(), spec)
+		return
+	}
+	version := installed.Name()
+	if installed.Name() == common.Config.GlobalVersion.Name() {
+		fmt.Printf("%s version %s is set as global, are you sure you want to uninstall it? [y/N]: ", language.Title(), version)
+		var response string
+		fmt.Scanln(&response)
+		if strings.TrimSpace(strings.ToLower(response)) != "y" {
+			return
+		}
+	}
+	lockLanguage(language, true)
+	defer unlockLanguage()
+	// Another process may have uninstalled the version meanwhile.
+	common.LoadConfig(language.Name())
+	installed = common.FindVersionByName(common.Config.InstalledVersions, version)
+	if installed == nil {
+		fmt.Printf("%s version %s is not installed\n", language.Title(), version)
+		return
+	}
+	err := os.RemoveAll(installed.Path)
+	if err != nil {
+		fmt.Printf("Failed to uninstall %s version %s: %v\n", language.Title(), version, err)
+		return
+	}
+	Rehash(language)
+	fmt.Printf("%s version %s uninstalled\n", language.Title(), version)
+}
+
+type listFilter struct {
+	vendor        string
+	line          string
+	installedOnly bool
+}
+
+func (f listFilter) apply(language common.Language, versions []common.Version) []common.Version {
+	filtered := []common.Version{}
+	for _, version := range versions {
+		if f.vendor != "" && version.Vendor != f.vendor {
+			continue
+		}
+		if f.line != "" && common.VersionLine(language, version) != f.line {
+			continue
+		}
+		if f.installedOnly && common.FindVersion(common.Config.InstalledVersions, version.Version, version.Vendor) == nil {
+			continue
+		}
+		filtered = append(filtered, version)
+	}
+	common.SortVersions(language, filtered)
+	return filtered
+}
+
+// printVersions prints versions grouped by release line. Versions must be
+// sorted with common.SortVersions.
+func printVersions(language common.Language, versions []common.Version, prefix func(common.Version) string) {
+	line := ""
+	for i, version := range versions {
+		if l := common.VersionLine(language, version); i == 0 || l != line {
+			line = l
+			fmt.Printf("  %s:\n", line)
+		}
+		fmt.Printf("  %s%s", prefix(version), version.Name())
+		fmt.Println()
+	}
+}
+
+func listInstalled(language common.Language, filter listFilter) {
+	if common.IsJSON() {
+		common.PrintJSON(common.DescribeVersions(filter.apply(language, common.Config.InstalledVersions)))
+		return
+	}
+	if len(common.Config.InstalledVersions) == 0 {
+		fmt.Println("No versions installed")
+		return
+	}
+	versions := filter.apply(language, common.Config.InstalledVersions)
+	if len(versions) == 0 {
+		fmt.Println("No installed versions match the filters")
+		return
+	}
+	active := activeVersionName()
+	fmt.Println("Installed Versions:")
+	printVersions(language, versions, func(version common.Version) string {
+		if version.Name() == active {
+			return " -> "
+		}
+		return "    "
+	})
+}
+
+func listAvailable(language common.Language, filter listFilter) {
+	versions, err := language.Source().FetchVersions(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		log.Fatalf("Error fetching versions: %v", err)
+	}
+	if common.IsJSON() {
+		common.PrintJSON(common.DescribeVersions(filter.apply(language, versions)))
+		return
+	}
+
+	if len(versions) == 0 {
+		fmt.Println("No versions available for your platform and architecture")
+		return
+	}
+	versions = filter.apply(language, versions)
+	if len(versions) == 0 {
+		fmt.Println("No available versions match the filters")
+		return
+	}
+
+	active := activeVersionName()
+	fmt.Println("Available Versions:")
+	printVersions(language, versions, func(version common.Version) string {
+		installed := common.FindVersion(common.Config.InstalledVersions, version.Version, version.Vendor)
+		if installed == nil {
+			return "    "
+		}
+		if installed.Name() == active {
+			return " -> "
+		}
+		return "  * "
+	})
+}
+
+func setGlobal(language common.Language, spec string) {
+	installed := resolveInstalled(spec)
+	if installed == nil {
+		log.Fatalf("%s version %s is not installed", language.Title(), spec)
+	}
+	version := installed.Name()
+	common.SetGlobalVersion(*installed)
+	switch runtime.GOOS {
+	case "windows":
+		setGlobalWindows(*installed)
+	case "linux", "android":
+		setGlobalLinux(*installed)
+	default:
+		log.Fatalf("Unknown operating system: %s", runtime.GOOS)
+	}
+	fmt.Printf("%s version %s set as global\n", language.Title(), version)
+	warnRequirements(language, *installed)
+}
+
+func setGlobalWindows(version common.Version) {
+	os.Remove(common.Config.CurrentVersionDir)
+	cmd := exec.Command("cmd", "/c", "mklink", "/J", common.Config.CurrentVersionDir, version.Path)
+	err := cmd.Run()
+	if err != nil {
+		log.Fatalf("Failed to set global version: %v", err)
+	}
+}
+
+func setGlobalLinux(version common.Version) {
+	os.Remove(common.Config.CurrentVersionDir)
+	err := os.Symlink(version.Path, common.Config.CurrentVersionDir)
+	if err != nil {
+		log.Fatalf("Failed to set global version: %v", err)
+	}
+}
+
+func activeVersionName() string {
+	active, _, err := common.ResolveVersion()
+	if err != nil || active == nil {
+		return ""
+	}
+	return active.Name()
+}
+
+func setLocal(language common.Language, spec string) {
+	installed := resolveInstalled(spec)
+	if installed == nil {
+		log.Fatalf("%s version %s is not installed", language.Title(), spec)
+	}
+	version := installed.Name()
+	cwd, err := os.Getwd()
+	if err != nil {
+		log.Fatalf("Failed to get current directory: %v", err)
+	}
+	err = common.SetLocalVersion(cwd, language.Name(), installed.Name())
+	if err != nil {
+		log.Fatalf("Failed to set local version: %v", err)
+	}
+	fmt.Printf("%s version %s set for %s\n", language.Title(), version, cwd)
+	warnRequirements(language, *installed)
+}
+
+func unsetLocalVersion(language common.Language) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		log.Fatalf("Failed to get current directory: %v", err)
+	}
+	err = common.SetLocalVersion(cwd, language.Name(), "")
+	if err != nil {
+		log.Fatalf("Failed to unset local version: %v", err)
+	}
+	fmt.Printf("Local %s version unset for %s\n", language.Title(), cwd)
+}
+
+func showLocal(language common.Language) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		log.Fatalf("Failed to get current directory: %v", err)
+	}
+	version, file := common.FindLocalVersion(cwd, language.Name())
+	if version == "" {
+		fmt.Printf("No local %s version set\n", language.Title())
+		return
+	}
+	fmt.Printf("%s (set by %s)\n", version, file)
+}
+
+func showShell(language common.Language) {
+	version := os.Getenv(common.ShellVersionVariable(language.Name()))
+	if version == "" {
+		fmt.Printf("No shell %s version set\n", language.Title())
+		return
+	}
+	fmt.Println(version)
+}
+
+// setShell prints shell code that sets or, for an empty version, removes the
+// shell override. The code is evaluated by the lenv shell function.
+func setShell(language common.Language, version string) {
+	shell := common.CurrentShell()
+	if shell == "" {
+		log.Fatalf("Shell integration is not enabled, the lenv shell function must be loaded to use 'lenv %s shell'", language.Name())
+	}
+	variable := common.ShellVersionVariable(language.Name())
+	if version == "" {
+		code, err := common.ShellUnsetEnv(shell, variable)
+		if err != nil {
+			log.Fatalf("Failed to unset shell version: %v", err)
+		}
+		fmt.Println(code)
+		env := language.Env(common.Version{Path: common.Config.CurrentVersionDir})
+		if _, err := os.Stat(common.Config.CurrentVersionDir); err != nil {
+			// No global version either, like lenv init.
+			unsetShellEnv(shell, env)
+			return
+		}
+		printShellEnv(shell, env)
+		return
+	}
+	installed := resolveInstalled(version)
+	if installed == nil {
+		log.Fatalf("%s version %s is not installed", language.Title(), version)
+	}
+	code, err := common.ShellSetEnv(shell, variable, installed.Name())
+	if err != nil {
+		log.Fatalf("Failed to set shell version: %v", err)
+	}
+	fmt.Println(code)
+	printShellEnv(shell, language.Env(*installed))
+	warnRequirements(language, *installed)
+}
+
+func unsetShellEnv(shell string, env map[string]string) {
+	for _, name := range common.SortedEnv(env) {
+		code, err := common.ShellUnsetEnv(shell, name)
+		if err != nil {
+			log.Fatalf("Failed to unset shell version: %v", err)
+		}
+		fmt.Println(code)
+	}
+}
+
+func printShellEnv(shell string, env map[string]string) {
+	for _, name := range common.SortedEnv(env) {
+		code, err := common.ShellSetEnv(shell, name, env[name])
+		if err != nil {
+			log.Fatalf("Failed to set shell version: %v", err)
+		}
+		fmt.Println(code)
+	}
+}
+
+func showCurrent(language common.Language) {
+	version, source, err := common.ResolveVersion()
+	if err != nil {
+		log.Fatalf("Failed to resolve %s version: %v", language.Title(), err)
+	}
+	if common.IsJSON() {
+		if version == nil {
+			common.PrintJSON(nil)
+		} else {
+			common.PrintJSON(common.DescribeVersion(*version))
+		}
+		return
+	}
+	if version == nil {
+		fmt.Printf("No %s version selected\n", language.Title())
+		return
+	}
+	fmt.Printf("%s (%s)\n", version.Name(), source)
+}
+
+// showInfo describes an installed or available version, or the active
+// version if spec is empty.
+func showInfo(language common.Language, spec string) {
+	var version *common.Version
+	if spec == "" {
+		active, _, err := common.ResolveVersion()
+		if err != nil {
+			log.Fatalf("Failed to resolve %s version: %v", language.Title(), err)
+		}
+		if active == nil {
+			log.Fatalf("No %s version selected", language.Title())
+		}
+		version = active
+	} else if version = resolveInstalled(spec); version == nil {
+		versions, err := language.Source().FetchVersions(runtime.GOOS, runtime.GOARCH)
+		if err != nil {
+			log.Fatalf("Error fetching versions: %v", err)
+		}
+		version, err = resolveSpecifier(versions, spec)
+		if err != nil {
+			log.Fatalf("Failed to resolve version: %v", err)
+		}
+		if version == nil {
+			log.Fatalf("No %s version matches %s", language.Title(), spec)
+		}
+		if version.Name() != spec {
+			fmt.Fprintf(os.Stderr, "Resolved %s to %s\n", spec, version.Name())
+		}
+	}
+	info := common.DescribeVersion(*version)
+	if common.IsJSON() {
+		common.PrintJSON(info)
+		return
+	}
+	fmt.Printf("Name:      %s\n", info.Name)
+	fmt.Printf("Version:   %s\n", info.Version)
+	if info.Vendor != "" {
+		fmt.Printf("Vendor:    %s\n", info.Vendor)
+	}
+	if info.Installed {
+		fmt.Printf("Path:      %s\n", info.Path)
+	}
+	fmt.Printf("Installed: %s\n", yesNo(info.Installed))
+	if info.Active {
+		fmt.Printf("Active:    yes (%s)\n", info.Source)
+	} else {
+		fmt.Printf("Active:    no\n")
+	}
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+// Rehash reloads the configuration of the language and rebuilds its shims.
+func Rehash(language common.Language) {
+	common.LoadConfig(language.Name())
+	rehash(language)
+}
+
+func rehash(language common.Language) {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, version := range common.Config.InstalledVersions {
+		for _, dir := range language.BinDirs(version) {
+			executables, err := common.FindExecutables(dir)
+			if err != nil {
+				log.Fatalf("Failed to read %s: %v", dir, err)
+			}
+			for _, name := range executables {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	err := common.WriteShims(language.Name(), names)
+	if err != nil {
+		log.Fatalf("Failed to write shims: %v", err)
+	}
+}
+
+func execCommand(language common.Language, args []string) {
+	version, source, err := common.ResolveVersion()
+	if err != nil {
+		log.Fatalf("Failed to resolve %s version: %v", language.Title(), err)
+	}
+	if version == nil {
+		log.Fatalf("No %s version selected, use 'lenv %s global' or 'lenv %s local'", language.Title(), language.Name(), language.Name())
+	}
+	dirs := language.BinDirs(*version)
+	path := common.FindExecutable(dirs, args[0])
+	if path == "" {
+		log.Fatalf("%s is not available in %s version %s (%s)", args[0], language.Title(), version.Name(), source)
+	}
+	env := common.PrependPath(os.Environ(), dirs)
+	for name, value := range language.Env(*version) {
+		env = common.SetEnv(env, name, value)
+	}
+	// The program may run for a long time, don't keep others waiting.
+	unlockLanguage()
+	err = common.Exec(path, args[1:], env)
+	log.Fatalf("Failed to run %s: %v", path, err)
+}
+
+// warnRequirements prints a warning to stderr if the environment does not
+// satisfy the requirements of version. Stdout may be evaluated by the shell.
+func warnRequirements(language common.Language, version common.Version) {
+	if warning := common.CheckRequiredJDK(version); warning != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s %s %s\n", language.Title(), version.Name(), warning)
+	}
+}
+
+// resolveInstalled resolves a version specifier against the installed
+// versions and reports the choice if it differs from the specifier.
+func resolveInstalled(spec string) *common.Version {
+	installed, err := resolveSpecifier(common.Config.InstalledVersions, spec)
+	if err != nil {
+		log.Fatalf("Failed to resolve version: %v", err)
+	}
+	if installed != nil && installed.Name() != spec {
+		fmt.Fprintf(os.Stderr, "Resolved %s to %s\n", spec, installed.Name())
+	}
+	return installed
+}
+
+// resolveAvailable resolves a version specifier against the published
+// versions. Plain version names that are not listed are used as they are,
+// so that versions missing from the listing can still be installed. If the
+// listing can't be fetched, only full "<version>-<vendor>" names are.
+func resolveAvailable(language common.Language, spec string) common.Version {
+	name, vendor := common.ParseVersionName(spec)
+	literal := common.Version{Version: name, Vendor: vendor}
+	isPattern := common.IsRangeSpecifier(spec) || name == "latest"
+	versions, err := language.Source().FetchVersions(runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		if isPattern || vendor == "" {
+			log.Fatalf("Error fetching versions: %v", err)
+		}
+		return literal
+	}
+	available, err := resolveSpecifier(versions, spec)
+	if err != nil {
+		log.Fatalf("Failed to resolve version: %v", err)
+	}
+	if available == nil {
+		if isPattern {
+			log.Fatalf("No %s version matches %s", language.Title(), spec)
+		}
+		return literal
+	}
+	if available.Name() != spec {
+		fmt.Fprintf(os.Stderr, "Resolved %s to %s\n", spec, available.Name())
+	}
+	return common.Version{Version: available.Version, Vendor: available.Vendor}
+}
+
+// resolveSpecifier is like common.ResolveSpecifier, but prefers the default
+// vendor of the language when spec names none.
+func resolveSpecifier(versions []common.Version, spec string) (*common.Version, error) {
+	if common.FindVersionByName(versions, spec) == nil {
+		if withVendor, ok := common.DefaultVendorSpec(spec); ok {
+			version, err := common.ResolveSpecifier(versions, withVendor)
+			if err == nil && version != nil {
+				return version, nil
+			}
+		}
+	}
+	return common.ResolveSpecifier(versions, spec)
+}
@@ -0,0 +1,139 @@
+package maven
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"kiber-io/lenv/common"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	ver "github.com/hashicorp/go-version"
+)
+
+const defaultBaseURL = "https://repo.maven.apache.org/maven2"
+
+const artifactPath = "org/apache/maven/apache-maven"
+
+type maven struct{}
+
+func init() {
+	common.RegisterLanguage(maven{})
+}
+
+func (maven) Name() string {
+	return "maven"
+}
+
+func (maven) Title() string {
+	return "Maven"
+}
+
+func (maven) Aliases() []string {
+	return []string{"mvn"}
+}
+
+func (maven) Source() common.ReleaseSource {
+	return repositorySource{BaseURL: common.MirrorURL("maven", defaultBaseURL)}
+}
+
+func (maven) BinDirs(version common.Version) []string {
+	return []string{filepath.Join(version.Path, "bin")}
+}
+
+func (maven) PostInstall(version common.Version) error {
+	return nil
+}
+
+func (maven) Env(version common.Version) map[string]string {
+	return map[string]string{"MAVEN_HOME": version.Path}
+}
+
+func (maven) VersionLine(version common.Version) string {
+	return common.MinorLine(version)
+}
+
+// RequiredJDK follows the Java requirements of the Maven release history.
+func (maven) RequiredJDK(version common.Version) string {
+	v, err := ver.NewVersion(version.Version)
+	if err != nil {
+		return ""
+	}
+	segments := v.Segments()
+	switch {
+	case segments[0] >= 4:
+		return "17"
+	case segments[0] == 3 && segments[1] >= 9:
+		return "8"
+	case segments[0] == 3 && segments[1] >= 3:
+		return "7"
+	case segments[0] == 3 && segments[1] == 2:
+		return "6"
+	default:
+		return "5"
+	}
+}
+
+// repositorySource reads Maven distributions from a Maven repository.
+type repositorySource struct {
+	BaseURL string
+}
+
+type metadata struct {
+	Versions []string `xml:"versioning>versions>version"`
+}
+
+func (s repositorySource) FetchVersions(platform string, arch string) ([]common.Version, error) {
+	resp, err := common.HTTPGet(fmt.Sprintf("%s/%s/maven-metadata.xml", s.BaseURL, artifactPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch metadata: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	var m metadata
+	err = xml.Unmarshal(body, &m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal XML: %v", err)
+	}
+
+	versions := []common.Version{}
+	for _, v := range m.Versions {
+		// Pre-releases such as "4.0.0-rc-2" are skipped like Gradle's, their
+		// names can't be told apart from a version with a vendor.
+		if strings.Contains(v, "-") {
+			continue
+		}
+		versions = append(versions, common.Version{Version: v})
+	}
+	return versions, nil
+}
+
+func (s repositorySource) DownloadURL(version common.Version, platform string, arch string) (string, error) {
+	return fmt.Sprintf("%s/%s/%s/apache-maven-%s-bin.zip", s.BaseURL, artifactPath, version.Version, version.Version), nil
+}
+
+// Checksum reads the SHA-512 file that is published next to every artifact.
+func (s repositorySource) Checksum(version common.Version, platform string, arch string) (common.Checksum, error) {
+	url, err := s.DownloadURL(version, platform, arch)
+	if err != nil {
+		return common.Checksum{}, err
+	}
+	data, err := common.FetchText(url + ".sha512")
+	if err != nil {
+		return common.Checksum{}, fmt.Errorf("failed to fetch checksum: %v", err)
+	}
+	fields := strings.Fields(data)
+	if len(fields) == 0 {
+		return common.Checksum{}, fmt.Errorf("empty checksum file for %s", url)
+	}
+	return common.Checksum{Algorithm: "sha512", Value: fields[0]}, nil
+}
@@ -0,0 +1,154 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"kiber-io/lenv/common"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const defaultBaseURL = "https://nodejs.org/dist"
+
+type node struct{}
+
+func init() {
+	common.RegisterLanguage(node{})
+}
+
+func (node) Name() string {
+	return "node"
+}
+
+func (node) Title() string {
+	return "Node.js"
+}
+
+func (node) Aliases() []string {
+	return []string{"n", "nodejs"}
+}
+
+func (node) Source() common.ReleaseSource {
+	return distSource{BaseURL: common.MirrorURL("node", defaultBaseURL)}
+}
+
+func (node) BinDirs(version common.Version) []string {
+	if runtime.GOOS == "windows" {
+		return []string{version.Path}
+	}
+	return []string{filepath.Join(version.Path, "bin")}
+}
+
+func (node) PostInstall(version common.Version) error {
+	return nil
+}
+
+func (node) SmokeTest() (string, []string) {
+	return "node", []string{"--version"}
+}
+
+func (node) Env(version common.Version) map[string]string {
+	return map[string]string{}
+}
+
+// distSource reads releases from a Node.js distribution server, which lists
+// them in index.json and serves archives from v<version>/.
+type distSource struct {
+	BaseURL string
+}
+
+type release struct {
+	Version string   `json:"version"`
+	Files   []string `json:"files"`
+}
+
+// platformFile returns the name that index.json uses for the archive of the
+// platform, and the archive suffix.
+func platformFile(platform string, arch string) (string, string) {
+	switch platform + "/" + arch {
+	case "linux/amd64":
+		return "linux-x64", ".tar.gz"
+	case "linux/arm64":
+		return "linux-arm64", ".tar.gz"
+	case "windows/amd64":
+		return "win-x64-zip", ".zip"
+	case "windows/arm64":
+		return "win-arm64-zip", ".zip"
+	default:
+		return "", ""
+	}
+}
+
+func (s distSource) FetchVersions(platform string, arch string) ([]common.Version, error) {
+	file, _ := platformFile(platform, arch)
+	if file == "" {
+		return nil, fmt.Errorf("unknown operating system and architecture: %s/%s", platform, arch)
+	}
+
+	resp, err := common.HTTPGet(s.BaseURL + "/index.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JSON: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	var releases []release
+	err = json.Unmarshal(body, &releases)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON: %v", err)
+	}
+
+	versions := []common.Version{}
+	for _, r := range releases {
+		for _, f := range r.Files {
+			if f == file {
+				versions = append(versions, common.Version{Version: strings.TrimPrefix(r.Version, "v")})
+				break
+			}
+		}
+	}
+	return versions, nil
+}
+
+func archiveName(version common.Version, platform string, arch string) (string, error) {
+	file, suffix := platformFile(platform, arch)
+	if file == "" {
+		return "", fmt.Errorf("unknown operating system and architecture: %s/%s", platform, arch)
+	}
+	return fmt.Sprintf("node-v%s-%s%s", version.Version, strings.TrimSuffix(file, "-zip"), suffix), nil
+}
+
+func (s distSource) DownloadURL(version common.Version, platform string, arch string) (string, error) {
+	name, err := archiveName(version, platform, arch)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/v%s/%s", s.BaseURL, version.Version, name), nil
+}
+
+// Checksum reads the SHASUMS256.txt manifest that is published with every release.
+func (s distSource) Checksum(version common.Version, platform string, arch string) (common.Checksum, error) {
+	name, err := archiveName(version, platform, arch)
+	if err != nil {
+		return common.Checksum{}, err
+	}
+	manifest, err := common.FetchText(fmt.Sprintf("%s/v%s/SHASUMS256.txt", s.BaseURL, version.Version))
+	if err != nil {
+		return common.Checksum{}, fmt.Errorf("failed to fetch checksum manifest: %v", err)
+	}
+	digest, ok := common.ParseChecksumManifest(manifest, name)
+	if !ok {
+		return common.Checksum{}, fmt.Errorf("checksum manifest has no entry for %s", name)
+	}
+	return common.Checksum{Algorithm: "sha256", Value: digest}, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"kiber-io/lenv/common"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newCacheCommand() *cobra.Command {
+	var olderThan string
+
+	var cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the download cache",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+	var listCmd = &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List cached archives",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			listCache()
+		},
+	}
+	var sizeCmd = &cobra.Command{
+		Use:   "size",
+		Short: "Show the size of the download cache",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			showCacheSize()
+		},
+	}
+	var cleanCmd = &cobra.Command{
+		Use:   "clean",
+		Short: "Remove cached archives",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var age time.Duration
+			if olderThan != "" {
+				var err error
+				age, err = common.ParseAge(olderThan)
+				if err != nil {
+					log.Fatalf("Invalid --older-than: %v", err)
+				}
+			}
+			cleanCache(age)
+		},
+	}
+	cleanCmd.Flags().StringVar(&olderThan, "older-than", "", "Only remove archives not used for this long, e.g. 30d or 12h")
+
+	cacheCmd.AddCommand(listCmd)
+	cacheCmd.AddCommand(sizeCmd)
+	cacheCmd.AddCommand(cleanCmd)
+	return cacheCmd
+}
+
+func listCache() {
+	cached, err := common.ListCache()
+	if err != nil {
+		log.Fatalf("Failed to list cache: %v", err)
+	}
+	if len(cached) == 0 {
+		fmt.Println("Cache is empty")
+		return
+	}
+	for _, entry := range cached {
+		checksum := fmt.Sprintf("%s:%.12s", entry.Checksum.Algorithm, entry.Checksum.Value)
+		if entry.Download {
+			checksum = "(download)"
+		}
+		fmt.Printf("  %-50s %10s  %s  %s\n", entry.Name, common.FormatSize(entry.Size),
+			entry.LastUsed.Format("2006-01-02"), checksum)
+	}
+}
+
+func showCacheSize() {
+	cached, err := common.ListCache()
+	if err != nil {
+		log.Fatalf("Failed to list cache: %v", err)
+	}
+	var total int64
+	for _, entry := range cached {
+		total += entry.Size
+	}
+	fmt.Printf("%s in %d archives (%s)\n", common.FormatSize(total), len(cached), common.CacheDir())
+}
+
+func cleanCache(olderThan time.Duration) {
+	cached, err := common.ListCache()
+	if err != nil {
+		log.Fatalf("Failed to list cache: %v", err)
+	}
+	removed := 0
+	var freed int64
+	for _, entry := range cached {
+		if olderThan > 0 && time.Since(entry.LastUsed) < olderThan {
+			continue
+		}
+		err := common.RemoveCached(entry)
+		if err != nil {
+			if entry.Download {
+				fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", entry.Name, err)
+				continue
+			}
+			log.Fatalf("Failed to remove %s from cache: %v", entry.Name, err)
+		}
+		removed++
+		freed += entry.Size
+	}
+	fmt.Printf("Removed %d archives, freed %s\n", removed, common.FormatSize(freed))
+}
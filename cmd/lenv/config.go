@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"kiber-io/lenv/common"
+	"log"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newConfigCommand() *cobra.Command {
+	var configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Manage settings in config.json",
+		Long:  "Manage settings in config.json. Known keys:\n  " + strings.Join(common.SettingKeys(), "\n  "),
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+	var getCmd = &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the value of a setting",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			getSetting(args[0])
+		},
+	}
+	var setCmd = &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Change a setting",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			setSetting(args[0], args[1])
+		},
+	}
+	var unsetCmd = &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a setting",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			setSetting(args[0], "")
+		},
+	}
+	var listCmd = &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List all settings",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			listSettings()
+		},
+	}
+
+	configCmd.AddCommand(getCmd)
+	configCmd.AddCommand(setCmd)
+	configCmd.AddCommand(unsetCmd)
+	configCmd.AddCommand(listCmd)
+	return configCmd
+}
+
+func getSetting(key string) {
+	settings, err := common.ReadSettings()
+	if err != nil {
+		log.Fatalf("Failed to load settings: %v", err)
+	}
+	value, err := settings.Get(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(value)
+}
+
+func setSetting(key string, value string) {
+	if language, ok := strings.CutPrefix(key, "global."); ok {
+		// The global version also switches the "current" link.
+		log.Fatalf("Use 'lenv %s global <version>' to change the global version", language)
+	}
+	err := common.UpdateSettings(func(settings *common.Settings) error {
+		return settings.Set(key, value)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func listSettings() {
+	settings, err := common.ReadSettings()
+	if err != nil {
+		log.Fatalf("Failed to load settings: %v", err)
+	}
+	for _, setting := range settings.List() {
+		fmt.Printf("%s = %s\n", setting[0], setting[1])
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"kiber-io/lenv/common"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var supportedShells = []string{"bash", "zsh", "fish", "pwsh"}
+
+func defaultRoot() string {
+	if dir := os.Getenv("LENV_HOME"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".lenv")
+}
+
+func detectShell() string {
+	if os.Getenv("PSModulePath") != "" && os.Getenv("SHELL") == "" {
+		return "pwsh"
+	}
+	return strings.TrimSuffix(filepath.Base(os.Getenv("SHELL")), ".exe")
+}
+
+// languageEnv returns the variables that languages export for the shell,
+// keeping shell overrides that are inherited from the parent shell.
+// Languages without a global or shell version export nothing, so that
+// toolchains installed outside lenv keep working.
+func languageEnv(root string) [][2]string {
+	env := [][2]string{}
+	for _, language := range common.Languages() {
+		path := filepath.Join(root, language.Name(), "current")
+		if version := os.Getenv(common.ShellVersionVariable(language.Name())); version != "" {
+			path = filepath.Join(root, language.Name(), "versions", version)
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		variables := language.Env(common.Version{Path: path})
+		for _, name := range common.SortedEnv(variables) {
+			env = append(env, [2]string{name, variables[name]})
+		}
+	}
+	return env
+}
+
+func initScript(shell string) (string, error) {
+	root := defaultRoot()
+	if root == "" {
+		return "", fmt.Errorf("failed to determine LENV_HOME")
+	}
+	if shell == "powershell" {
+		shell = "pwsh"
+	}
+	exports := []string{}
+	for _, variable := range append([][2]string{{"LENV_HOME", root}}, languageEnv(root)...) {
+		code, err := common.ShellSetEnv(shell, variable[0], variable[1])
+		if err != nil {
+			return "", fmt.Errorf("unsupported shell: %s, supported shells: %s", shell, strings.Join(supportedShells, ", "))
+		}
+		exports = append(exports, code)
+	}
+	switch shell {
+	case "bash", "zsh":
+		return posixInitScript(shell, strings.Join(exports, "\n")), nil
+	case "fish":
+		return fishInitScript(strings.Join(exports, "\n")), nil
+	case "pwsh":
+		exe, err := os.Executable()
+		if err != nil {
+			return "", fmt.Errorf("failed to get lenv executable path: %v", err)
+		}
+		return pwshInitScript(strings.Join(exports, "\n"), exe), nil
+	default:
+		return "", fmt.Errorf("unsupported shell: %s, supported shells: %s", shell, strings.Join(supportedShells, ", "))
+	}
+}
+
+func posixInitScript(shell string, exports string) string {
+	completion := "source <(command lenv completion bash)"
+	if shell == "zsh" {
+		completion = "if (( $+functions[compdef] )); then\n  source <(command lenv completion zsh)\nfi"
+	}
+	return fmt.Sprintf(`%[1]s
+case ":$PATH:" in
+  *":$LENV_HOME/shims:"*) ;;
+  *) export PATH="$LENV_HOME/shims:$LENV_HOME/bin:$PATH" ;;
+esac
+lenv() {
+  if [ "$2" = "shell" ] && [ "$#" -gt 2 ]; then
+    eval "$(LENV_SHELL=%[2]s command lenv "$@")"
+  else
+    command lenv "$@"
+  fi
+}
+%[3]s
+`, exports, shell, completion)
+}
+
+func fishInitScript(exports string) string {
+	return fmt.Sprintf(`%s
+if not contains -- "$LENV_HOME/shims" $PATH
+  set -gx PATH "$LENV_HOME/shims" "$LENV_HOME/bin" $PATH
+end
+function lenv
+  if test (count $argv) -gt 2; and test "$argv[2]" = "shell"
+    env LENV_SHELL=fish lenv $argv | source
+  else
+    command lenv $argv
+  end
+end
+command lenv completion fish | source
+`, exports)
+}
+
+func pwshInitScript(exports string, exe string) string {
+	return fmt.Sprintf(`%[1]s
+$lenvShims = Join-Path $env:LENV_HOME 'shims'
+if (-not ($env:Path -split [System.IO.Path]::PathSeparator -contains $lenvShims)) {
+    $env:Path = $lenvShims + [System.IO.Path]::PathSeparator + (Join-Path $env:LENV_HOME 'bin') + [System.IO.Path]::PathSeparator + $env:Path
+}
+function lenv {
+    if ($args.Count -gt 2 -and $args[1] -eq 'shell') {
+        $env:LENV_SHELL = 'pwsh'
+        try {
+            & '%[2]s' @args | Out-String | Invoke-Expression
+        } finally {
+            Remove-Item Env:LENV_SHELL
+        }
+    } else {
+        & '%[2]s' @args
+    }
+}
+& '%[2]s' completion powershell | Out-String | Invoke-Expression
+`, exports, strings.ReplaceAll(exe, "'", "''"))
+}
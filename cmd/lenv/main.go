@@ -1,9 +1,16 @@
 package main
 
 import (
-	"kiber-io/lenv/cmd/languages/java"
-	"kiber-io/lenv/cmd/languages/python"
+	"fmt"
+	"kiber-io/lenv/cmd/languages"
+	_ "kiber-io/lenv/cmd/languages/golang"
+	_ "kiber-io/lenv/cmd/languages/gradle"
+	_ "kiber-io/lenv/cmd/languages/java"
+	_ "kiber-io/lenv/cmd/languages/maven"
+	_ "kiber-io/lenv/cmd/languages/node"
+	_ "kiber-io/lenv/cmd/languages/python"
 	"kiber-io/lenv/common"
+	"log"
 
 	"github.com/spf13/cobra"
 )
@@ -28,33 +35,50 @@ func main() {
 		},
 		Hidden: true,
 	}
-	var javaCmd = &cobra.Command{
-		Use:     "java",
-		Aliases: []string{"j"},
-		Short:   "Manage Java versions",
+	var rehashCmd = &cobra.Command{
+		Use:   "rehash",
+		Short: "Rebuild shims for all installed versions",
+		Args:  cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) == 0 {
-				_ = cmd.Help()
-				return
+			for _, language := range common.Languages() {
+				languages.RehashLocked(language)
 			}
 		},
 	}
-	var pythonCmd = &cobra.Command{
-		Use:     "python",
-		Aliases: []string{"p", "py"},
-		Short:   "Manage Python versions",
+	var initCmd = &cobra.Command{
+		Use:       "init [shell]",
+		Short:     "Print shell integration code",
+		Long:      "Print shell integration code. Add eval \"$(lenv init bash)\" to your shell profile to enable it.",
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: supportedShells,
 		Run: func(cmd *cobra.Command, args []string) {
-			if len(args) == 0 {
-				_ = cmd.Help()
-				return
+			shell := detectShell()
+			if len(args) > 0 {
+				shell = args[0]
 			}
+			script, err := initScript(shell)
+			if err != nil {
+				log.Fatalf("Failed to generate shell integration: %v", err)
+			}
+			fmt.Print(script)
 		},
 	}
+	if err := common.ApplySettings(); err != nil {
+		log.Fatalf("Failed to load settings: %v", err)
+	}
+	rootCmd.PersistentFlags().DurationVar(&common.HTTP.ConnectTimeout, "connect-timeout", common.HTTP.ConnectTimeout, "Timeout for connecting to servers (env LENV_CONNECT_TIMEOUT)")
+	rootCmd.PersistentFlags().DurationVar(&common.HTTP.ReadTimeout, "read-timeout", common.HTTP.ReadTimeout, "Timeout for waiting on data from servers (env LENV_READ_TIMEOUT)")
+	rootCmd.PersistentFlags().IntVar(&common.HTTP.Retries, "retries", common.HTTP.Retries, "Number of retries of failed requests (env LENV_RETRIES)")
+	rootCmd.PersistentFlags().VarP(&common.Output, "output", "o", "Output format of list, current, info and install: text or json")
+	rootCmd.PersistentFlags().DurationVar(&common.LockTimeout, "lock-timeout", common.LockTimeout, "How long to wait for other lenv processes (env LENV_LOCK_TIMEOUT)")
 	rootCmd.AddCommand(printRootCmd)
-	rootCmd.AddCommand(javaCmd)
-	rootCmd.AddCommand(pythonCmd)
+	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(rehashCmd)
+	rootCmd.AddCommand(newCacheCommand())
+	rootCmd.AddCommand(newConfigCommand())
+	for _, language := range common.Languages() {
+		rootCmd.AddCommand(languages.NewCommand(language))
+	}
 	rootCmd.AddCommand(versionCmd)
-	java.Init(javaCmd)
-	python.Init(pythonCmd)
 	rootCmd.Execute()
 }
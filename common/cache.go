@@ -0,0 +1,266 @@
+package common
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CacheEntry is a downloaded archive kept in the cache. Archives are stored
+// by their checksum as "<cache>/<algorithm>-<digest>/<file name>", so the
+// same archive is never downloaded twice, whatever mirror it came from.
+type CacheEntry struct {
+	Checksum Checksum
+	Name     string
+	Path     string
+	Size     int64
+	// LastUsed is the time the archive was downloaded or last installed from.
+	LastUsed time.Time
+	// Download is set for files in the downloads directory: interrupted
+	// downloads and downloads that were never moved into the cache. They
+	// have no checksum.
+	Download bool
+}
+
+// CacheDir returns the directory of the download cache.
+func CacheDir() string {
+	return filepath.Join(GetRoot(), "cache")
+}
+
+func cacheEntryDir(checksum Checksum) (string, error) {
+	if checksum.Algorithm == "" || strings.ContainsAny(checksum.Algorithm, `/\.`) {
+		return "", fmt.Errorf("invalid checksum algorithm: %s", checksum.Algorithm)
+	}
+	if _, err := hex.DecodeString(checksum.Value); err != nil || checksum.Value == "" {
+		return "", fmt.Errorf("invalid checksum: %s", checksum.Value)
+	}
+	return filepath.Join(CacheDir(), checksum.Algorithm+"-"+strings.ToLower(checksum.Value)), nil
+}
+
+// ArchiveFileName returns the file name of the archive at a download URL.
+func ArchiveFileName(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Path != "" {
+		return path.Base(u.Path)
+	}
+	return path.Base(rawURL)
+}
+
+// FindCached returns the path of the cached archive with the checksum and
+// marks it as used.
+func FindCached(checksum Checksum) (string, bool) {
+	dir, err := cacheEntryDir(checksum)
+	if err != nil {
+		return "", false
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			file := filepath.Join(dir, entry.Name())
+			now := time.Now()
+			_ = os.Chtimes(file, now, now)
+			return file, true
+		}
+	}
+	return "", false
+}
+
+// AddToCache moves a downloaded archive into the cache and returns its new path.
+func AddToCache(file string, checksum Checksum, name string) (string, error) {
+	dir, err := cacheEntryDir(checksum)
+	if err != nil {
+		return "", err
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %v", err)
+	}
+	dest := filepath.Join(dir, filepath.Base(name))
+	if err := os.Rename(file, dest); err == nil {
+		return dest, nil
+	}
+	// The temp directory may be on another file system than LENV_HOME.
+	err = copyFile(file, dest)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to add archive to cache: %v", err)
+	}
+	os.Remove(file)
+	return dest, nil
+}
+
+func copyFile(src string, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// downloadsDir returns the directory of downloads in progress, see DownloadFile.
+func downloadsDir() string {
+	return filepath.Join(CacheDir(), "downloads")
+}
+
+// listDownloads returns the files left in the downloads directory.
+func listDownloads() ([]CacheEntry, error) {
+	files, err := os.ReadDir(downloadsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read downloads directory: %v", err)
+	}
+	downloads := []CacheEntry{}
+	for _, file := range files {
+		// Validators and locks belong to a partial file.
+		if strings.HasSuffix(file.Name(), ".etag") || strings.HasSuffix(file.Name(), ".lock") {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		downloads = append(downloads, CacheEntry{
+			Name:     file.Name(),
+			Path:     filepath.Join(downloadsDir(), file.Name()),
+			Size:     info.Size(),
+			LastUsed: info.ModTime(),
+			Download: true,
+		})
+	}
+	return downloads, nil
+}
+
+// ListCache returns the cached archives and leftover downloads, most
+// recently used first.
+func ListCache() ([]CacheEntry, error) {
+	dirs, err := os.ReadDir(CacheDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cache directory: %v", err)
+	}
+	cached := []CacheEntry{}
+	for _, dir := range dirs {
+		algorithm, digest, ok := strings.Cut(dir.Name(), "-")
+		if !dir.IsDir() || !ok {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(CacheDir(), dir.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cache directory: %v", err)
+		}
+		for _, file := range files {
+			info, err := file.Info()
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			cached = append(cached, CacheEntry{
+				Checksum: Checksum{Algorithm: algorithm, Value: digest},
+				Name:     file.Name(),
+				Path:     filepath.Join(CacheDir(), dir.Name(), file.Name()),
+				Size:     info.Size(),
+				LastUsed: info.ModTime(),
+			})
+		}
+	}
+	downloads, err := listDownloads()
+	if err != nil {
+		return nil, err
+	}
+	cached = append(cached, downloads...)
+	sort.SliceStable(cached, func(i, j int) bool {
+		return cached[i].LastUsed.After(cached[j].LastUsed)
+	})
+	return cached, nil
+}
+
+// CacheEnabled tells whether downloaded archives are kept in the cache.
+func CacheEnabled() bool {
+	enabled := Config.Settings.Cache.Enabled
+	return enabled == nil || *enabled
+}
+
+// PruneCache removes the archives that were not used for longer than the
+// max age of the cache settings.
+func PruneCache() error {
+	if Config.Settings.Cache.MaxAge == "" {
+		return nil
+	}
+	maxAge, err := ParseAge(Config.Settings.Cache.MaxAge)
+	if err != nil {
+		return fmt.Errorf("invalid cache.max_age: %v", err)
+	}
+	cached, err := ListCache()
+	if err != nil {
+		return err
+	}
+	for _, entry := range cached {
+		if time.Since(entry.LastUsed) > maxAge {
+			// Downloads in progress are pruned another time.
+			if err := RemoveCached(entry); err != nil && !entry.Download {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RemoveCached deletes an archive from the cache. A partial download that
+// another process is still writing is not removed.
+func RemoveCached(entry CacheEntry) error {
+	if !entry.Download {
+		return os.RemoveAll(filepath.Dir(entry.Path))
+	}
+	if strings.HasSuffix(entry.Path, ".partial") {
+		lock, err := tryLockPath(entry.Path + ".lock")
+		if err != nil {
+			return err
+		}
+		if lock == nil {
+			return fmt.Errorf("%s is being downloaded", entry.Name)
+		}
+		defer lock.Unlock()
+		os.Remove(strings.TrimSuffix(entry.Path, ".partial") + ".etag")
+		os.Remove(entry.Path + ".lock")
+	}
+	err := os.Remove(entry.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// FormatSize formats a number of bytes for humans, e.g. "187.3 MiB".
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,90 @@
+package common
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Checksum is the expected digest of a release archive.
+type Checksum struct {
+	Algorithm string
+	Value     string
+}
+
+func (c Checksum) String() string {
+	return fmt.Sprintf("%s:%s", c.Algorithm, c.Value)
+}
+
+// ParseChecksumManifest looks up fileName in a manifest in the format of
+// sha256sum, i.e. lines of "<hex digest>  <file name>".
+func ParseChecksumManifest(data string, fileName string) (string, bool) {
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if strings.TrimPrefix(fields[1], "*") == fileName {
+			return strings.ToLower(fields[0]), true
+		}
+	}
+	return "", false
+}
+
+// FetchText downloads a small text file such as a checksum manifest.
+func FetchText(url string) (string, error) {
+	resp, err := HTTPGet(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: bad status: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %v", err)
+	}
+	return string(body), nil
+}
+
+// FileChecksum computes the digest of the file at path.
+func FileChecksum(path string, algorithm string) (Checksum, error) {
+	var h hash.Hash
+	switch algorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return Checksum{}, fmt.Errorf("unsupported checksum algorithm: %s", algorithm)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return Checksum{}, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+	if _, err := io.Copy(h, file); err != nil {
+		return Checksum{}, fmt.Errorf("failed to read file: %v", err)
+	}
+	return Checksum{Algorithm: algorithm, Value: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// VerifyChecksum computes the digest of the file at path and compares it with
+// the expected checksum. The computed checksum is returned in both cases.
+func VerifyChecksum(path string, expected Checksum) (Checksum, error) {
+	actual, err := FileChecksum(path, expected.Algorithm)
+	if err != nil {
+		return Checksum{}, err
+	}
+	if !strings.EqualFold(actual.Value, strings.TrimSpace(expected.Value)) {
+		return actual, fmt.Errorf("checksum mismatch: expected %s, got %s", expected, actual)
+	}
+	return actual, nil
+}
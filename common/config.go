@@ -9,15 +9,17 @@ import (
 )
 
 type config struct {
+	Language          string
 	InstalledVersions []Version
 	VersionsDir       string
 	CurrentVersionDir string
 	GlobalVersion     Version
+	// Settings holds the contents of the configuration file.
+	Settings Settings
 }
 
 var Config config
 var rootDir string
-var languageDir string
 
 func GetRoot() string {
 	dir := os.Getenv("LENV_HOME")
@@ -29,14 +31,20 @@ func GetRoot() string {
 }
 
 func LoadConfig(language string) {
+	Config = readConfig(language)
+}
+
+// readConfig loads the state of a language from LENV_HOME.
+func readConfig(language string) config {
 	rootDir = GetRoot()
 	if _, err := os.Stat(rootDir); os.IsNotExist(err) {
 		log.Fatal("LENV_HOME directory not found")
 	}
-	if language != "java" && language != "python" {
+	if FindLanguage(language) == nil {
 		log.Fatalf("Unknown language: %s", language)
 	}
-	languageDir = filepath.Join(rootDir, strings.ToLower(language))
+	c := config{Language: strings.ToLower(language)}
+	languageDir := filepath.Join(rootDir, c.Language)
 	if _, err := os.Stat(languageDir); os.IsNotExist(err) {
 		err := os.Mkdir(languageDir, 0755)
 		if err != nil {
@@ -50,56 +58,152 @@ func LoadConfig(language string) {
 			log.Fatalf("Failed to create versions directory: %v", err)
 		}
 	}
-	Config.VersionsDir = versionsDir
+	c.VersionsDir = versionsDir
 	folders, err := os.ReadDir(versionsDir)
 	if err != nil {
 		log.Fatalf("Failed to read language directory: %v", err)
 	}
 	for _, folder := range folders {
+		if strings.HasPrefix(folder.Name(), ".") {
+			// Staging directories of installs in progress.
+			continue
+		}
 		if folder.IsDir() {
-			parts := strings.Split(folder.Name(), "-")
+			if IsIncomplete(filepath.Join(versionsDir, folder.Name())) {
+				// Stderr: readConfig also runs for shims, whose stdout is the program's.
+				fmt.Fprintf(os.Stderr, "Warning: skipping %s, its installation did not finish, install it again\n", folder.Name())
+				continue
+			}
+			name, vendor := ParseVersionName(folder.Name())
 			version := Version{
-				Version: parts[0],
-				Vendor:  parts[1],
+				Version: name,
+				Vendor:  vendor,
 				Path:    filepath.Join(versionsDir, folder.Name()),
 			}
-			Config.InstalledVersions = append(Config.InstalledVersions, version)
+			info, err := ReadInstallInfo(version)
+			if err != nil {
+				log.Fatalf("Failed to read install info of %s: %v", folder.Name(), err)
+			}
+			version.RequiresJDK = info.RequiresJDK
+			c.InstalledVersions = append(c.InstalledVersions, version)
 		} else {
-			fmt.Printf("Unexpected file found in versions directory: %s", folder.Name())
+			Statusf("Unexpected file found in versions directory: %s\n", folder.Name())
 		}
 	}
-	Config.CurrentVersionDir = filepath.Join(languageDir, "current")
-	globalVersionFile := filepath.Join(languageDir, "global")
-	if _, err := os.Stat(globalVersionFile); os.IsNotExist(err) {
-		err := os.WriteFile(globalVersionFile, []byte(""), 0644)
-		if err != nil {
-			log.Fatalf("Failed to create global version file: %v", err)
-		}
+	c.CurrentVersionDir = filepath.Join(languageDir, "current")
+	c.Settings, err = ReadSettings()
+	if err != nil {
+		log.Fatalf("Failed to load settings: %v", err)
 	}
-	data, err := os.ReadFile(globalVersionFile)
+	version, err := readGlobalVersion(c.Settings, c.Language, languageDir)
 	if err != nil {
-		log.Fatalf("Failed to read global version file: %v", err)
+		log.Fatalf("Failed to read global version: %v", err)
 	}
-	version := string(data)
-	for _, v := range Config.InstalledVersions {
+	for _, v := range c.InstalledVersions {
 		if v.Name() == version {
-			Config.GlobalVersion = v
+			c.GlobalVersion = v
 			break
 		}
 	}
-	if Config.GlobalVersion == (Version{}) {
-		err := os.WriteFile(globalVersionFile, []byte(""), 0644)
-		if err != nil {
-			log.Fatalf("Failed to write global version file: %v", err)
-		}
+	return c
+}
+
+// readGlobalVersion returns the global version of a language from the
+// configuration file. The global version used to be kept in a "global" file
+// in the language directory, which is moved into the configuration file.
+// The move happens under the lock of the configuration file, since other
+// processes may be migrating or setting the global version at the same time.
+func readGlobalVersion(settings Settings, language string, languageDir string) (string, error) {
+	if version, ok := settings.Global[language]; ok {
+		return version, nil
+	}
+	globalVersionFile := filepath.Join(languageDir, "global")
+	if _, err := os.Stat(globalVersionFile); os.IsNotExist(err) {
+		return "", nil
 	}
+	var version string
+	err := UpdateSettings(func(settings *Settings) error {
+		if current, ok := settings.Global[language]; ok {
+			version = current
+		} else {
+			data, err := os.ReadFile(globalVersionFile)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			version = strings.TrimSpace(string(data))
+			if version != "" {
+				if err := settings.Set("global."+language, version); err != nil {
+					return err
+				}
+			}
+		}
+		err := os.Remove(globalVersionFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	})
+	return version, err
+}
+
+// VersionPath returns the directory that an installed version occupies.
+func VersionPath(version Version) string {
+	return filepath.Join(Config.VersionsDir, version.Name())
 }
 
 func SetGlobalVersion(version Version) {
-	globalVerionFile := filepath.Join(languageDir, "global")
-	err := os.WriteFile(globalVerionFile, []byte(version.Name()), 0644)
+	err := UpdateSettings(func(settings *Settings) error {
+		return settings.Set("global."+Config.Language, version.Name())
+	})
 	if err != nil {
-		log.Fatalf("Failed to write global version file: %v", err)
+		log.Fatalf("Failed to save global version: %v", err)
 	}
+	Config.Settings.Set("global."+Config.Language, version.Name())
 	Config.GlobalVersion = version
 }
+
+// ResolveVersion returns the version that applies in the current shell and
+// directory: the shell override, then the nearest project pin or project file,
+// then the global version. The second value describes where the selection came from.
+func ResolveVersion() (*Version, string, error) {
+	return Config.resolveVersion()
+}
+
+// ResolveLanguageVersion is like ResolveVersion, but for another language than
+// the loaded one. Config is left untouched.
+func ResolveLanguageVersion(language string) (*Version, string, error) {
+	c := readConfig(language)
+	return c.resolveVersion()
+}
+
+func (c config) resolveVersion() (*Version, string, error) {
+	variable := ShellVersionVariable(c.Language)
+	if name := os.Getenv(variable); name != "" {
+		installed, err := ResolveSpecifier(c.InstalledVersions, name)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid version set by %s: %v", variable, err)
+		}
+		if installed == nil {
+			return nil, "", fmt.Errorf("version %s set by %s is not installed", name, variable)
+		}
+		return installed, fmt.Sprintf("set by %s", variable), nil
+	}
+	cwd, err := os.Getwd()
+	if err == nil {
+		name, file := FindProjectVersion(cwd, FindLanguage(c.Language), c.InstalledVersions)
+		if name != "" {
+			installed, err := ResolveSpecifier(c.InstalledVersions, name)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid version set by %s: %v", file, err)
+			}
+			if installed == nil {
+				return nil, "", fmt.Errorf("version %s set by %s is not installed", name, file)
+			}
+			return installed, fmt.Sprintf("set by %s", file), nil
+		}
+	}
+	if c.GlobalVersion == (Version{}) {
+		return nil, "", nil
+	}
+	return &c.GlobalVersion, "global", nil
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package common
+
+import "syscall"
+
+// Exec replaces the current process with the program at path.
+func Exec(path string, args []string, env []string) error {
+	return syscall.Exec(path, append([]string{path}, args...), env)
+}
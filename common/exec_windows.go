@@ -0,0 +1,29 @@
+//go:build windows
+
+package common
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// Exec runs the program at path and exits with its exit code, since Windows
+// cannot replace the current process.
+func Exec(path string, args []string, env []string) error {
+	cmd := exec.Command(path, args...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		return err
+	}
+	os.Exit(0)
+	return nil
+}
@@ -0,0 +1,224 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GithubReleases is a release source backed by the releases of a GitHub
+// repository. Each release is tagged with a version and has one zip asset
+// per platform and vendor, named "<platform prefix>-<vendor>.zip", and a
+// SHA256SUMS manifest covering all of them, signed with minisign in
+// SHA256SUMS.minisig.
+type GithubReleases struct {
+	Repository string
+	// BaseURL serves the release downloads, e.g. https://github.com.
+	BaseURL string
+	// APIURL serves the REST API, e.g. https://api.github.com.
+	APIURL string
+}
+
+const (
+	githubURL    = "https://github.com"
+	githubAPIURL = "https://api.github.com"
+)
+
+// NewGithubReleases returns the release source of a repository on GitHub or
+// on the mirror configured for name, see MirrorURL. The API of a mirror is
+// looked up with the name "<name>-api" and defaults to the API path of GitHub
+// Enterprise, "<mirror>/api/v3".
+func NewGithubReleases(name string, repository string) GithubReleases {
+	baseURL := MirrorURL(name, githubURL)
+	defaultAPIURL := githubAPIURL
+	if baseURL != githubURL {
+		defaultAPIURL = baseURL + "/api/v3"
+	}
+	return GithubReleases{
+		Repository: repository,
+		BaseURL:    baseURL,
+		APIURL:     MirrorURL(name+"-api", defaultAPIURL),
+	}
+}
+
+func (s GithubReleases) FetchVersions(platform string, arch string) ([]Version, error) {
+	platformPrefix := GetPlatformPrefix(platform, arch)
+	if platformPrefix == "" {
+		return nil, fmt.Errorf("unknown operating system and architecture: %s/%s", platform, arch)
+	}
+
+	versions := []ServerVersion{}
+	url := fmt.Sprintf("%s/repos/%s/releases?per_page=100", s.APIURL, s.Repository)
+	for url != "" {
+		var page []ServerVersion
+		next, err := s.fetchPage(url, &page)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, page...)
+		url = next
+	}
+
+	filteredVersions := []Version{}
+	for _, serverVersion := range versions {
+		for _, asset := range serverVersion.Assets {
+			if strings.HasPrefix(asset.Name, platformPrefix) {
+				version := Version{
+					Version: serverVersion.TagName,
+					Path:    "",
+					Vendor:  ParseAssetName(asset.Name),
+				}
+				filteredVersions = append(filteredVersions, version)
+			}
+		}
+	}
+
+	return filteredVersions, nil
+}
+
+// fetchPage fetches one page of an API listing into v and returns the URL of
+// the next page, if any.
+func (s GithubReleases) fetchPage(url string, v any) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch JSON: %v", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := s.tokenFor(req.URL); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := DoRequest(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch JSON: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if err := githubRateLimitError(resp); err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch %s: bad status: %s", url, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %v", err)
+	}
+	err = json.Unmarshal(body, v)
+	if err != nil {
+		return "", fmt.Errorf("failed to unmarshal JSON: %v", err)
+	}
+	return nextPageURL(resp.Header.Get("Link")), nil
+}
+
+// GithubToken returns the token for GitHub API requests from
+// LENV_GITHUB_TOKEN or GITHUB_TOKEN. Authenticated requests have a much
+// higher rate limit.
+func GithubToken() string {
+	if token := os.Getenv("LENV_GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	return os.Getenv("GITHUB_TOKEN")
+}
+
+// tokenFor returns the GitHub token to send with a request to u. It is only
+// sent to the API host of the source, and only if that is api.github.com or
+// a host listed in LENV_GITHUB_TOKEN_HOSTS, e.g. a GitHub Enterprise server,
+// so that mirrors and other hosts named in Link headers never see it.
+func (s GithubReleases) tokenFor(u *url.URL) string {
+	api, err := url.Parse(s.APIURL)
+	if err != nil || !strings.EqualFold(u.Host, api.Host) || u.Scheme != api.Scheme {
+		return ""
+	}
+	if !isGithubTokenHost(u.Host) {
+		return ""
+	}
+	return GithubToken()
+}
+
+func isGithubTokenHost(host string) bool {
+	trusted := []string{"api.github.com"}
+	trusted = append(trusted, strings.Split(os.Getenv("LENV_GITHUB_TOKEN_HOSTS"), ",")...)
+	for _, h := range trusted {
+		if h = strings.TrimSpace(h); h != "" && strings.EqualFold(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// githubRateLimitError explains a response that was refused because the rate
+// limit of the API is used up.
+func githubRateLimitError(resp *http.Response) error {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return nil
+	}
+	message := "GitHub API rate limit exceeded"
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		at := time.Unix(reset, 0)
+		message += fmt.Sprintf(", it resets at %s (in %s)", at.Format("15:04:05"), time.Until(at).Round(time.Second))
+	}
+	if GithubToken() == "" {
+		message += "; set LENV_GITHUB_TOKEN or GITHUB_TOKEN to raise the limit"
+	}
+	return errors.New(message)
+}
+
+// nextPageURL returns the rel="next" target of a Link header.
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(part, ";")
+		if !ok {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(target), "<>")
+			}
+		}
+	}
+	return ""
+}
+
+func (s GithubReleases) DownloadURL(version Version, platform string, arch string) (string, error) {
+	platformPrefix := GetPlatformPrefix(platform, arch)
+	if platformPrefix == "" {
+		return "", fmt.Errorf("unknown operating system and architecture: %s/%s", platform, arch)
+	}
+	return fmt.Sprintf("%s/%s-%s.zip", s.releaseURL(version), platformPrefix, version.Vendor), nil
+}
+
+func (s GithubReleases) Checksum(version Version, platform string, arch string) (Checksum, error) {
+	platformPrefix := GetPlatformPrefix(platform, arch)
+	if platformPrefix == "" {
+		return Checksum{}, fmt.Errorf("unknown operating system and architecture: %s/%s", platform, arch)
+	}
+	manifestURL := s.releaseURL(version) + "/SHA256SUMS"
+	manifest, err := FetchText(manifestURL)
+	if err != nil {
+		return Checksum{}, fmt.Errorf("failed to fetch checksum manifest: %v", err)
+	}
+	if err := VerifySignature(manifest, manifestURL+".minisig"); err != nil {
+		return Checksum{}, fmt.Errorf("failed to verify checksum manifest: %v", err)
+	}
+	fileName := fmt.Sprintf("%s-%s.zip", platformPrefix, version.Vendor)
+	digest, ok := ParseChecksumManifest(manifest, fileName)
+	if !ok {
+		return Checksum{}, fmt.Errorf("checksum manifest has no entry for %s", fileName)
+	}
+	return Checksum{Algorithm: "sha256", Value: digest}, nil
+}
+
+func (s GithubReleases) releaseURL(version Version) string {
+	return fmt.Sprintf("%s/%s/releases/download/%s", s.BaseURL, s.Repository, version.Version)
+}
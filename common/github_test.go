@@ -0,0 +1,47 @@
+package common
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestGithubTokenFor(t *testing.T) {
+	t.Setenv("LENV_GITHUB_TOKEN", "secret")
+	t.Setenv("LENV_GITHUB_TOKEN_HOSTS", "github.example.com")
+
+	tests := []struct {
+		name   string
+		apiURL string
+		url    string
+		want   string
+	}{
+		{"github", githubAPIURL, "https://api.github.com/repos/a/b/releases", "secret"},
+		{"enterprise", "https://github.example.com/api/v3", "https://github.example.com/api/v3/repos/a/b/releases", "secret"},
+		{"mirror", "https://artifactory.example.com/api/github", "https://artifactory.example.com/api/github/repos/a/b/releases", ""},
+		{"next page on another host", githubAPIURL, "https://evil.example.com/repos/a/b/releases?page=2", ""},
+		{"next page over http", githubAPIURL, "http://api.github.com/repos/a/b/releases?page=2", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := GithubReleases{Repository: "a/b", APIURL: tt.apiURL}
+			if got := s.tokenFor(u); got != tt.want {
+				t.Errorf("tokenFor(%s) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	link := `<https://api.github.com/repositories/1/releases?per_page=100&page=2>; rel="next", <https://api.github.com/repositories/1/releases?per_page=100&page=5>; rel="last"`
+	want := "https://api.github.com/repositories/1/releases?per_page=100&page=2"
+	if got := nextPageURL(link); got != want {
+		t.Errorf("nextPageURL() = %s, want %s", got, want)
+	}
+	if got := nextPageURL(""); got != "" {
+		t.Errorf("nextPageURL(\"\") = %s, want empty", got)
+	}
+}
@@ -0,0 +1,200 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPSettings configures all network calls of lenv.
+type HTTPSettings struct {
+	// ConnectTimeout limits establishing a connection, including TLS.
+	ConnectTimeout time.Duration
+	// ReadTimeout limits waiting for the response and for each read of its body,
+	// so that a stalled download fails instead of hanging.
+	ReadTimeout time.Duration
+	// Retries is how many times a request is repeated after a network error or
+	// a 429 or 5xx response.
+	Retries int
+}
+
+// HTTP holds the settings of the shared client. The defaults can be changed
+// with LENV_CONNECT_TIMEOUT, LENV_READ_TIMEOUT and LENV_RETRIES, which are in
+// turn overridden by the --connect-timeout, --read-timeout and --retries flags.
+var HTTP = HTTPSettings{
+	ConnectTimeout: durationFromEnv("LENV_CONNECT_TIMEOUT", 30*time.Second),
+	ReadTimeout:    durationFromEnv("LENV_READ_TIMEOUT", 60*time.Second),
+	Retries:        intFromEnv("LENV_RETRIES", 3),
+}
+
+const maxRetryDelay = time.Minute
+
+var client *http.Client
+var clientOnce sync.Once
+
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", name, err)
+	}
+	return duration
+}
+
+func intFromEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Fatalf("Invalid %s: %s", name, value)
+	}
+	return n
+}
+
+// HTTPClient returns the client shared by all network calls. It is created
+// on first use, so flags must be parsed before.
+func HTTPClient() *http.Client {
+	clientOnce.Do(func() {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = (&net.Dialer{Timeout: HTTP.ConnectTimeout, KeepAlive: 30 * time.Second}).DialContext
+		transport.TLSHandshakeTimeout = HTTP.ConnectTimeout
+		client = &http.Client{Transport: transport}
+	})
+	return client
+}
+
+// HTTPGet is like http.Get, but uses the shared client, see DoRequest.
+func HTTPGet(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return DoRequest(req)
+}
+
+// DoRequest sends a request without a body with the shared client. Network
+// errors and 429 and 5xx responses are retried with exponential backoff and
+// jitter, waiting as long as the server asks for in Retry-After. The last
+// response is returned as is when retries are exhausted.
+func DoRequest(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := doOnce(req)
+		retryable := err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retryable || attempt >= HTTP.Retries {
+			return resp, err
+		}
+		delay := backoff(attempt)
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			if after, ok := retryAfter(resp); ok {
+				delay = after
+			}
+			resp.Body.Close()
+		}
+		fmt.Fprintf(os.Stderr, "Request to %s failed (%s), retrying in %s (%d/%d)\n",
+			req.URL.Redacted(), reason, delay.Round(time.Second), attempt+1, HTTP.Retries)
+		time.Sleep(delay)
+	}
+}
+
+func doOnce(req *http.Request) (*http.Response, error) {
+	if HTTP.ReadTimeout <= 0 {
+		return HTTPClient().Do(req)
+	}
+	ctx, cancel := context.WithCancel(req.Context())
+	body := &timeoutBody{cancel: cancel}
+	body.timer = time.AfterFunc(HTTP.ReadTimeout, func() {
+		body.expired.Store(true)
+		cancel()
+	})
+	resp, err := HTTPClient().Do(req.WithContext(ctx))
+	if err != nil {
+		body.timer.Stop()
+		cancel()
+		if body.expired.Load() {
+			return nil, fmt.Errorf("no response within %s", HTTP.ReadTimeout)
+		}
+		return nil, err
+	}
+	body.timer.Reset(HTTP.ReadTimeout)
+	body.ReadCloser = resp.Body
+	resp.Body = body
+	return resp, nil
+}
+
+// timeoutBody cancels the request when no data arrives for the read timeout.
+type timeoutBody struct {
+	io.ReadCloser
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	expired atomic.Bool
+}
+
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && b.expired.Load() {
+		return n, fmt.Errorf("no data received for %s", HTTP.ReadTimeout)
+	}
+	b.timer.Reset(HTTP.ReadTimeout)
+	return n, err
+}
+
+func (b *timeoutBody) Close() error {
+	b.timer.Stop()
+	b.cancel()
+	return b.ReadCloser.Close()
+}
+
+func backoff(attempt int) time.Duration {
+	delay := maxRetryDelay
+	if attempt < 6 {
+		delay = time.Second << attempt
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryAfter parses the Retry-After header, given in seconds or as a date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		delay = time.Until(date)
+	} else {
+		return 0, false
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay, true
+}
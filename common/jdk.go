@@ -0,0 +1,57 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JDKRequirement is implemented by languages whose versions need a minimum
+// Java version to run, such as build tools.
+type JDKRequirement interface {
+	// RequiredJDK returns the minimum Java major version that a version
+	// needs, e.g. "17", or an empty string if it is unknown.
+	RequiredJDK(version Version) string
+}
+
+// JavaMajor returns the major version of a Java version string. Legacy
+// versions such as "1.8.0" are treated as 8.
+func JavaMajor(version string) (int, error) {
+	parts := strings.Split(version, ".")
+	if parts[0] == "1" && len(parts) > 1 {
+		parts = parts[1:]
+	}
+	major, err := strconv.Atoi(strings.SplitN(parts[0], "_", 2)[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid Java version: %s", version)
+	}
+	return major, nil
+}
+
+// CheckRequiredJDK returns a warning if the active Java version does not
+// satisfy the JDK requirement of version, or an empty string if it does. The
+// warning reads as a continuation of the version's name.
+func CheckRequiredJDK(version Version) string {
+	if version.RequiresJDK == "" {
+		return ""
+	}
+	required, err := strconv.Atoi(version.RequiresJDK)
+	if err != nil {
+		return fmt.Sprintf("has an invalid JDK requirement: %s", version.RequiresJDK)
+	}
+	active, source, err := ResolveLanguageVersion("java")
+	if err != nil {
+		return fmt.Sprintf("requires Java %d or newer, but the active Java version cannot be resolved: %v", required, err)
+	}
+	if active == nil {
+		return fmt.Sprintf("requires Java %d or newer, but no Java version is selected", required)
+	}
+	major, err := JavaMajor(active.Version)
+	if err != nil {
+		return fmt.Sprintf("requires Java %d or newer, but the version of the active Java %s is unknown", required, active.Name())
+	}
+	if major < required {
+		return fmt.Sprintf("requires Java %d or newer, but the active Java version is %s (%s)", required, active.Name(), source)
+	}
+	return ""
+}
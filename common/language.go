@@ -0,0 +1,87 @@
+package common
+
+import (
+	"sort"
+	"strings"
+)
+
+// Language describes a toolchain managed by lenv. Implementations register
+// themselves with RegisterLanguage and get the install, uninstall, list,
+// global, local and shell commands for free.
+type Language interface {
+	// Name is used for the command and the data directory, e.g. "java".
+	Name() string
+	// Title is used in messages, e.g. "Java".
+	Title() string
+	Aliases() []string
+	// Source returns where versions of the language are published.
+	Source() ReleaseSource
+	// BinDirs returns the directories of an installed version that contain executables.
+	BinDirs(version Version) []string
+	// PostInstall runs after the archive of a version has been extracted to
+	// version.Path, which is a staging directory that is renamed on success.
+	PostInstall(version Version) error
+	// Env returns the environment variables to export for a version.
+	Env(version Version) map[string]string
+}
+
+// SmokeTester is implemented by languages that can check that a freshly
+// extracted version works before it is moved into place.
+type SmokeTester interface {
+	// SmokeTest returns an executable from BinDirs and its arguments that must
+	// run successfully, e.g. "java" and "-version".
+	SmokeTest() (string, []string)
+}
+
+// InstallFinalizer is implemented by languages with install steps that must
+// run at the final path of a version, e.g. because they write it into scripts.
+type InstallFinalizer interface {
+	// FinalizeInstall runs after the staged version was moved to version.Path.
+	// The version is removed again if it fails.
+	FinalizeInstall(version Version) error
+}
+
+// ReleaseSource lists the published versions of a language and locates their archives.
+type ReleaseSource interface {
+	FetchVersions(platform string, arch string) ([]Version, error)
+	DownloadURL(version Version, platform string, arch string) (string, error)
+	// Checksum returns the published digest of the archive of a version.
+	Checksum(version Version, platform string, arch string) (Checksum, error)
+}
+
+var languages []Language
+
+func RegisterLanguage(language Language) {
+	languages = append(languages, language)
+}
+
+// Languages returns all registered languages in registration order.
+func Languages() []Language {
+	return languages
+}
+
+// FindLanguage returns the registered language with the given name or alias.
+func FindLanguage(name string) Language {
+	name = strings.ToLower(name)
+	for _, language := range languages {
+		if language.Name() == name {
+			return language
+		}
+		for _, alias := range language.Aliases() {
+			if alias == name {
+				return language
+			}
+		}
+	}
+	return nil
+}
+
+// SortedEnv returns the names of the variables in env in a stable order.
+func SortedEnv(env map[string]string) []string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
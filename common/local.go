@@ -0,0 +1,126 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// VersionFileName is the name of the per-project file that pins versions.
+// Each non-empty line has the form "<language> <version>", e.g. "java 11-openjdk".
+const VersionFileName = ".lenv-version"
+
+func readVersionFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"), nil
+}
+
+func parseVersionLine(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", ""
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return "", ""
+	}
+	return strings.ToLower(fields[0]), fields[1]
+}
+
+// ProjectDetector is implemented by languages whose projects declare the
+// version they need in their own files, such as go.mod.
+type ProjectDetector interface {
+	// DetectVersion returns the version requested by the project files in dir
+	// (not its parents) and the path of the file that requested it. Installed
+	// versions are passed in to pick from when the files only give a range.
+	DetectVersion(dir string, installed []Version) (string, string)
+}
+
+func findPinnedVersion(dir string, language string) (string, string) {
+	path := filepath.Join(dir, VersionFileName)
+	if lines, err := readVersionFile(path); err == nil {
+		for _, line := range lines {
+			lang, version := parseVersionLine(line)
+			if lang == language {
+				return version, path
+			}
+		}
+	}
+	return "", ""
+}
+
+// FindLocalVersion walks up from dir and returns the version pinned for the
+// language by the nearest version file, together with the path of that file.
+func FindLocalVersion(dir string, language string) (string, string) {
+	return findProjectVersion(dir, language, nil, nil)
+}
+
+// FindProjectVersion is like FindLocalVersion, but also asks the language's
+// ProjectDetector, if it has one, at each directory. A version file wins over
+// project files in the same directory.
+func FindProjectVersion(dir string, language Language, installed []Version) (string, string) {
+	detector, _ := language.(ProjectDetector)
+	return findProjectVersion(dir, language.Name(), detector, installed)
+}
+
+func findProjectVersion(dir string, language string, detector ProjectDetector, installed []Version) (string, string) {
+	for {
+		if version, file := findPinnedVersion(dir, language); version != "" {
+			return version, file
+		}
+		if detector != nil {
+			if version, file := detector.DetectVersion(dir, installed); version != "" {
+				return version, file
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// SetLocalVersion pins the version for the language in the version file of dir.
+// An empty version removes the pin. Entries for other languages are preserved.
+func SetLocalVersion(dir string, language string, version string) error {
+	path := filepath.Join(dir, VersionFileName)
+	lines, err := readVersionFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read version file: %v", err)
+	}
+	result := []string{}
+	replaced := false
+	for _, line := range lines {
+		lang, _ := parseVersionLine(line)
+		if lang == language {
+			if version != "" && !replaced {
+				result = append(result, fmt.Sprintf("%s %s", language, version))
+			}
+			replaced = true
+			continue
+		}
+		if strings.TrimSpace(line) != "" {
+			result = append(result, line)
+		}
+	}
+	if version != "" && !replaced {
+		result = append(result, fmt.Sprintf("%s %s", language, version))
+	}
+	if len(result) == 0 {
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove version file: %v", err)
+		}
+		return nil
+	}
+	err = os.WriteFile(path, []byte(strings.Join(result, "\n")+"\n"), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write version file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LockTimeout is how long LockLanguage waits for other lenv processes. It
+// can be changed with LENV_LOCK_TIMEOUT or the --lock-timeout flag.
+var LockTimeout = durationFromEnv("LENV_LOCK_TIMEOUT", 2*time.Minute)
+
+// Lock is an advisory lock on the data of a language in LENV_HOME. Commands
+// that only read hold it shared, commands that change versions hold it
+// exclusively. The operating system releases it when the process exits.
+type Lock struct {
+	file      *os.File
+	pidPath   string
+	exclusive bool
+}
+
+// LockLanguage locks the data of a language, waiting up to LockTimeout for
+// other processes to release it.
+func LockLanguage(language string, exclusive bool) (*Lock, error) {
+	root := GetRoot()
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil, fmt.Errorf("LENV_HOME directory not found")
+	}
+	dir := filepath.Join(root, strings.ToLower(language))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %v", err)
+	}
+	return lockFile(filepath.Join(dir, ".lock"), exclusive)
+}
+
+// tryLockPath locks the file at path exclusively, creating it if needed. It
+// doesn't wait: if another process holds the lock, it returns nil.
+func tryLockPath(path string) (*Lock, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %v", err)
+	}
+	ok, err := tryLockFile(file, true)
+	if err != nil || !ok {
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock %s: %v", path, err)
+		}
+		return nil, nil
+	}
+	// The holder may have removed the file after it was opened, see
+	// Lock.Remove; the lock of a removed file protects nothing.
+	opened, err := file.Stat()
+	current, statErr := os.Stat(path)
+	if err != nil || statErr != nil || !os.SameFile(opened, current) {
+		unlockFile(file)
+		file.Close()
+		return nil, nil
+	}
+	return &Lock{file: file, pidPath: path + ".pid"}, nil
+}
+
+// Remove deletes the lock file while the lock is held, before Unlock.
+// Processes that wait for the lock of the same path must check that the
+// file they locked is still there.
+func (l *Lock) Remove() error {
+	return os.Remove(l.file.Name())
+}
+
+// lockFile locks the file at path, creating it if needed.
+func lockFile(path string, exclusive bool) (*Lock, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %v", err)
+	}
+	lock := &Lock{file: file, pidPath: path + ".pid", exclusive: exclusive}
+	deadline := time.Now().Add(LockTimeout)
+	waiting := false
+	for {
+		ok, err := tryLockFile(file, exclusive)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock %s: %v", path, err)
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("timed out after %s waiting for %s", LockTimeout, lock.holder())
+		}
+		if !waiting {
+			waiting = true
+			fmt.Fprintf(os.Stderr, "Waiting for %s to finish...\n", lock.holder())
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if exclusive {
+		_ = os.WriteFile(lock.pidPath, []byte(strconv.Itoa(os.Getpid())), 0644)
+	}
+	return lock, nil
+}
+
+// holder describes the process that holds the lock, as far as it is known.
+// Only exclusive holders record their PID, and a process that exited with
+// log.Fatal leaves its PID behind, so it is only named while it runs.
+func (l *Lock) holder() string {
+	data, err := os.ReadFile(l.pidPath)
+	if err == nil {
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err == nil && pid != os.Getpid() && processAlive(pid) {
+			return fmt.Sprintf("lenv process %d", pid)
+		}
+	}
+	return "another lenv process"
+}
+
+// Unlock releases the lock.
+func (l *Lock) Unlock() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	if l.exclusive {
+		os.Remove(l.pidPath)
+	}
+	err := unlockFile(l.file)
+	l.file.Close()
+	l.file = nil
+	return err
+}
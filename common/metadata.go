@@ -0,0 +1,69 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const installInfoFileName = ".lenv-install.json"
+
+// incompleteFileName marks a version directory whose install was moved into
+// place but not finalized yet, see MarkIncomplete.
+const incompleteFileName = ".lenv-incomplete"
+
+// InstallInfo is recorded in the directory of every installed version.
+type InstallInfo struct {
+	RequiresJDK string `json:"requires_jdk,omitempty"`
+	// Checksum is the verified digest of the installed archive, e.g. "sha256:<hex>".
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// ReadInstallInfo reads the install info of a version. Versions installed
+// before install info was recorded get an empty one.
+func ReadInstallInfo(version Version) (InstallInfo, error) {
+	var info InstallInfo
+	data, err := os.ReadFile(filepath.Join(version.Path, installInfoFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return info, nil
+		}
+		return info, err
+	}
+	err = json.Unmarshal(data, &info)
+	if err != nil {
+		return info, fmt.Errorf("failed to unmarshal JSON: %v", err)
+	}
+	return info, nil
+}
+
+func WriteInstallInfo(version Version, info InstallInfo) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %v", err)
+	}
+	return os.WriteFile(filepath.Join(version.Path, installInfoFileName), data, 0644)
+}
+
+// MarkIncomplete marks the install at path as unfinished until
+// MarkComplete is called, so that an install that crashes after it was moved
+// into place is not taken for a working version.
+func MarkIncomplete(path string) error {
+	return os.WriteFile(filepath.Join(path, incompleteFileName), nil, 0644)
+}
+
+// MarkComplete removes the mark of MarkIncomplete.
+func MarkComplete(path string) error {
+	err := os.Remove(filepath.Join(path, incompleteFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// IsIncomplete reports whether the install at path was not finished.
+func IsIncomplete(path string) bool {
+	_, err := os.Stat(filepath.Join(path, incompleteFileName))
+	return err == nil
+}
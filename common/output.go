@@ -0,0 +1,116 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// OutputFormat is the format of command results, set with --output.
+type OutputFormat string
+
+const (
+	OutputText OutputFormat = "text"
+	OutputJSON OutputFormat = "json"
+)
+
+// Output is the format of command results. In the JSON format stdout only
+// carries the JSON document and status messages go to stderr.
+var Output = OutputText
+
+func (f *OutputFormat) String() string {
+	return string(*f)
+}
+
+func (f *OutputFormat) Set(value string) error {
+	switch OutputFormat(value) {
+	case OutputText, OutputJSON:
+		*f = OutputFormat(value)
+		return nil
+	default:
+		return fmt.Errorf("must be %s or %s", OutputText, OutputJSON)
+	}
+}
+
+func (f *OutputFormat) Type() string {
+	return "format"
+}
+
+// IsJSON reports whether results are printed as JSON.
+func IsJSON() bool {
+	return Output == OutputJSON
+}
+
+// statusWriter returns where status messages such as "Downloading..." go.
+func statusWriter() io.Writer {
+	if IsJSON() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// Statusf prints a status message: to stdout with the text output and to
+// stderr with the JSON output.
+func Statusf(format string, args ...any) {
+	fmt.Fprintf(statusWriter(), format, args...)
+}
+
+// Statusln is like Statusf, but formats like fmt.Println.
+func Statusln(args ...any) {
+	fmt.Fprintln(statusWriter(), args...)
+}
+
+// PrintJSON prints v as indented JSON to stdout.
+func PrintJSON(v any) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(v)
+}
+
+// VersionInfo describes a version in the JSON output. The field names are a
+// stable interface for scripts; fields are only ever added.
+type VersionInfo struct {
+	// Name is the full version name, e.g. "17.0.1-temurin".
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Vendor is empty for languages without vendors.
+	Vendor string `json:"vendor"`
+	// Path is the installation directory, empty if the version is not installed.
+	Path      string `json:"path"`
+	Installed bool   `json:"installed"`
+	Active    bool   `json:"active"`
+	// Source tells why the version is active: "global", "set by <file>" for a
+	// project version or "set by LENV_<LANGUAGE>_VERSION" for a shell version.
+	// It is empty if the version is not active.
+	Source string `json:"source"`
+}
+
+// DescribeVersions returns the VersionInfo of versions of the loaded language.
+func DescribeVersions(versions []Version) []VersionInfo {
+	// Errors are reported by the commands that need the active version.
+	active, source, _ := ResolveVersion()
+	infos := []VersionInfo{}
+	for _, version := range versions {
+		info := VersionInfo{
+			Name:    version.Name(),
+			Version: version.Version,
+			Vendor:  version.Vendor,
+		}
+		if installed := FindVersionByName(Config.InstalledVersions, version.Name()); installed != nil {
+			info.Path = installed.Path
+			info.Installed = true
+		}
+		if active != nil && active.Name() == version.Name() {
+			info.Active = true
+			info.Source = source
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// DescribeVersion is like DescribeVersions for a single version.
+func DescribeVersion(version Version) VersionInfo {
+	return DescribeVersions([]Version{version})[0]
+}
@@ -0,0 +1,122 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Progress reports the progress of a long download or extraction on stderr.
+// On a terminal it draws a progress bar that is redrawn in place, otherwise
+// it prints a plain line every few seconds so logs of CI jobs stay readable.
+// Progress is an io.Writer that counts the bytes written to it, so it can be
+// used with io.MultiWriter or io.TeeReader.
+type Progress struct {
+	total    int64
+	done     int64
+	initial  int64
+	start    time.Time
+	lastDraw time.Time
+	drawn    int64
+	terminal bool
+	finished bool
+}
+
+const barWidth = 30
+
+// NewProgress starts reporting progress towards total bytes, of which
+// initial are already done, e.g. when a download is resumed. A total of 0
+// or less means the size is unknown.
+func NewProgress(total int64, initial int64) *Progress {
+	p := &Progress{
+		total:    total,
+		done:     initial,
+		initial:  initial,
+		start:    time.Now(),
+		terminal: isTerminal(os.Stderr),
+	}
+	if !p.terminal {
+		// The first plain line is printed after the first interval.
+		p.lastDraw = p.start
+	}
+	return p
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0 && os.Getenv("TERM") != "dumb"
+}
+
+func (p *Progress) Write(b []byte) (int, error) {
+	p.Add(int64(len(b)))
+	return len(b), nil
+}
+
+// Add records n more bytes as done.
+func (p *Progress) Add(n int64) {
+	p.done += n
+	interval := 5 * time.Second
+	if p.terminal {
+		interval = 100 * time.Millisecond
+	}
+	if time.Since(p.lastDraw) >= interval {
+		p.draw()
+	}
+}
+
+// Finish draws the final state and ends the progress line.
+func (p *Progress) Finish() {
+	if p.finished {
+		return
+	}
+	p.finished = true
+	if p.total > 0 && p.done < p.total {
+		p.total = p.done
+	}
+	if p.drawn != p.done || p.terminal {
+		p.draw()
+	}
+	if p.terminal {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+func (p *Progress) draw() {
+	p.lastDraw = time.Now()
+	p.drawn = p.done
+	elapsed := time.Since(p.start)
+	rate := float64(0)
+	if elapsed > 0 {
+		rate = float64(p.done-p.initial) / elapsed.Seconds()
+	}
+	status := FormatSize(p.done)
+	if p.total > 0 {
+		status += " / " + FormatSize(p.total)
+	}
+	if rate > 0 {
+		status += fmt.Sprintf("  %s/s", FormatSize(int64(rate)))
+		if p.total > 0 && p.done < p.total {
+			eta := time.Duration(float64(p.total-p.done) / rate * float64(time.Second))
+			status += "  ETA " + eta.Round(time.Second).String()
+		}
+	}
+	percent := ""
+	if p.total > 0 {
+		percent = fmt.Sprintf("%3d%% ", p.done*100/p.total)
+	}
+	if !p.terminal {
+		fmt.Fprintf(os.Stderr, "  %s%s\n", percent, status)
+		return
+	}
+	bar := ""
+	if p.total > 0 {
+		filled := int(p.done * barWidth / p.total)
+		bar = "[" + strings.Repeat("=", filled) + strings.Repeat(" ", barWidth-filled) + "] "
+	}
+	// Pad to clear what is left of a longer previous line.
+	fmt.Fprintf(os.Stderr, "\r  %s%s%-40s", percent, bar, status)
+}
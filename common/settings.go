@@ -0,0 +1,335 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const settingsFileName = "config.json"
+
+// Settings is the user configuration stored in LENV_HOME/config.json.
+type Settings struct {
+	// Global maps languages to their global version.
+	Global map[string]string `json:"global,omitempty"`
+	// DefaultVendor maps languages to the vendor preferred when a version
+	// specifier names none, e.g. "java": "temurin".
+	DefaultVendor map[string]string `json:"default_vendor,omitempty"`
+	// Mirrors maps source names, e.g. "java" or "java-api", to base URLs.
+	Mirrors map[string]string `json:"mirrors,omitempty"`
+	Network NetworkSettings   `json:"network"`
+	Cache   CacheSettings     `json:"cache"`
+}
+
+// NetworkSettings are the defaults of HTTPSettings. Environment variables
+// and flags take precedence.
+type NetworkSettings struct {
+	ConnectTimeout string `json:"connect_timeout,omitempty"`
+	ReadTimeout    string `json:"read_timeout,omitempty"`
+	Retries        *int   `json:"retries,omitempty"`
+}
+
+// CacheSettings is the policy of the download cache.
+type CacheSettings struct {
+	// Enabled keeps downloaded archives in the cache, true if unset.
+	Enabled *bool `json:"enabled,omitempty"`
+	// MaxAge removes archives that were not used for this long after every
+	// install, e.g. "30d". Nothing is removed if unset.
+	MaxAge string `json:"max_age,omitempty"`
+}
+
+// SettingsPath returns the path of the configuration file.
+func SettingsPath() string {
+	return filepath.Join(GetRoot(), settingsFileName)
+}
+
+// ReadSettings reads the configuration file. A missing file gives empty settings.
+func ReadSettings() (Settings, error) {
+	var settings Settings
+	data, err := os.ReadFile(SettingsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return settings, nil
+		}
+		return settings, fmt.Errorf("failed to read %s: %v", settingsFileName, err)
+	}
+	err = json.Unmarshal(data, &settings)
+	if err != nil {
+		return settings, fmt.Errorf("failed to unmarshal %s: %v", settingsFileName, err)
+	}
+	return settings, nil
+}
+
+// UpdateSettings applies change to the configuration file. The file is
+// locked while it is read and written, and replaced atomically.
+func UpdateSettings(change func(settings *Settings) error) error {
+	lock, err := lockFile(filepath.Join(GetRoot(), ".config.lock"), true)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	settings, err := ReadSettings()
+	if err != nil {
+		return err
+	}
+	err = change(&settings)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %v", err)
+	}
+	tmp := SettingsPath() + ".tmp"
+	err = os.WriteFile(tmp, append(data, '\n'), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %v", settingsFileName, err)
+	}
+	err = os.Rename(tmp, SettingsPath())
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %v", settingsFileName, err)
+	}
+	return nil
+}
+
+// ApplySettings sets the network defaults from the configuration file where
+// no environment variable overrides them. It must run before flags are
+// parsed, and does nothing if LENV_HOME is not set.
+func ApplySettings() error {
+	if os.Getenv("LENV_HOME") == "" {
+		return nil
+	}
+	settings, err := ReadSettings()
+	if err != nil {
+		return err
+	}
+	network := settings.Network
+	if network.ConnectTimeout != "" && os.Getenv("LENV_CONNECT_TIMEOUT") == "" {
+		if HTTP.ConnectTimeout, err = time.ParseDuration(network.ConnectTimeout); err != nil {
+			return fmt.Errorf("invalid network.connect_timeout: %v", err)
+		}
+	}
+	if network.ReadTimeout != "" && os.Getenv("LENV_READ_TIMEOUT") == "" {
+		if HTTP.ReadTimeout, err = time.ParseDuration(network.ReadTimeout); err != nil {
+			return fmt.Errorf("invalid network.read_timeout: %v", err)
+		}
+	}
+	if network.Retries != nil && os.Getenv("LENV_RETRIES") == "" {
+		HTTP.Retries = *network.Retries
+	}
+	return nil
+}
+
+// mapSettings are the sections of Settings that map a name to a value.
+var mapSettings = map[string]func(s *Settings) *map[string]string{
+	"global":         func(s *Settings) *map[string]string { return &s.Global },
+	"default_vendor": func(s *Settings) *map[string]string { return &s.DefaultVendor },
+	"mirrors":        func(s *Settings) *map[string]string { return &s.Mirrors },
+}
+
+type scalarSetting struct {
+	get func(s *Settings) string
+	set func(s *Settings, value string) error
+}
+
+var scalarSettings = map[string]scalarSetting{
+	"network.connect_timeout": {
+		get: func(s *Settings) string { return s.Network.ConnectTimeout },
+		set: func(s *Settings, value string) error {
+			if _, err := time.ParseDuration(value); err != nil && value != "" {
+				return err
+			}
+			s.Network.ConnectTimeout = value
+			return nil
+		},
+	},
+	"network.read_timeout": {
+		get: func(s *Settings) string { return s.Network.ReadTimeout },
+		set: func(s *Settings, value string) error {
+			if _, err := time.ParseDuration(value); err != nil && value != "" {
+				return err
+			}
+			s.Network.ReadTimeout = value
+			return nil
+		},
+	},
+	"network.retries": {
+		get: func(s *Settings) string {
+			if s.Network.Retries == nil {
+				return ""
+			}
+			return strconv.Itoa(*s.Network.Retries)
+		},
+		set: func(s *Settings, value string) error {
+			if value == "" {
+				s.Network.Retries = nil
+				return nil
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("not a number of retries: %s", value)
+			}
+			s.Network.Retries = &n
+			return nil
+		},
+	},
+	"cache.enabled": {
+		get: func(s *Settings) string {
+			if s.Cache.Enabled == nil {
+				return ""
+			}
+			return strconv.FormatBool(*s.Cache.Enabled)
+		},
+		set: func(s *Settings, value string) error {
+			if value == "" {
+				s.Cache.Enabled = nil
+				return nil
+			}
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("not a boolean: %s", value)
+			}
+			s.Cache.Enabled = &enabled
+			return nil
+		},
+	},
+	"cache.max_age": {
+		get: func(s *Settings) string { return s.Cache.MaxAge },
+		set: func(s *Settings, value string) error {
+			if _, err := ParseAge(value); err != nil && value != "" {
+				return err
+			}
+			s.Cache.MaxAge = value
+			return nil
+		},
+	},
+}
+
+// Get returns the value of a setting by its key, e.g. "mirrors.java" or
+// "network.retries".
+func (s *Settings) Get(key string) (string, error) {
+	if setting, ok := scalarSettings[key]; ok {
+		return setting.get(s), nil
+	}
+	section, name, ok := strings.Cut(key, ".")
+	if field, known := mapSettings[section]; known && ok && name != "" {
+		return (*field(s))[name], nil
+	}
+	return "", fmt.Errorf("unknown setting: %s", key)
+}
+
+// Set changes a setting by its key. An empty value removes it.
+func (s *Settings) Set(key string, value string) error {
+	if setting, ok := scalarSettings[key]; ok {
+		if err := setting.set(s, value); err != nil {
+			return fmt.Errorf("invalid value for %s: %v", key, err)
+		}
+		return nil
+	}
+	section, name, ok := strings.Cut(key, ".")
+	field, known := mapSettings[section]
+	if !known || !ok || name == "" {
+		return fmt.Errorf("unknown setting: %s", key)
+	}
+	values := field(s)
+	if value == "" {
+		delete(*values, name)
+		return nil
+	}
+	if *values == nil {
+		*values = map[string]string{}
+	}
+	(*values)[name] = value
+	return nil
+}
+
+// List returns all settings that have a value as key and value pairs,
+// sorted by key.
+func (s *Settings) List() [][2]string {
+	list := [][2]string{}
+	for key, setting := range scalarSettings {
+		if value := setting.get(s); value != "" {
+			list = append(list, [2]string{key, value})
+		}
+	}
+	for section, field := range mapSettings {
+		for name, value := range *field(s) {
+			list = append(list, [2]string{section + "." + name, value})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i][0] < list[j][0]
+	})
+	return list
+}
+
+// SettingKeys returns the keys that Settings.Set accepts, with "<name>" for
+// the sections that map names to values.
+func SettingKeys() []string {
+	keys := []string{}
+	for key := range scalarSettings {
+		keys = append(keys, key)
+	}
+	for section := range mapSettings {
+		keys = append(keys, section+".<name>")
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ParseAge is like time.ParseDuration, but also accepts days, e.g. "30d".
+func ParseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid number of days: %s", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// MirrorVariable returns the environment variable that overrides the base
+// URL of a source, e.g. LENV_JAVA_API_MIRROR for "java-api".
+func MirrorVariable(name string) string {
+	return "LENV_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_MIRROR"
+}
+
+// MirrorURL returns the base URL of a source: the MirrorVariable of name,
+// then the entry for name in the mirrors of the configuration file, then
+// fallback. Trailing slashes are removed.
+func MirrorURL(name string, fallback string) string {
+	url := os.Getenv(MirrorVariable(name))
+	if url == "" {
+		settings, err := ReadSettings()
+		if err != nil {
+			log.Fatalf("Failed to load settings: %v", err)
+		}
+		url = settings.Mirrors[name]
+	}
+	if url == "" {
+		url = fallback
+	}
+	return strings.TrimSuffix(url, "/")
+}
+
+// DefaultVendorSpec returns spec with the default vendor of the loaded
+// language appended, if one is configured and spec is a partial version or
+// "latest" without a vendor.
+func DefaultVendorSpec(spec string) (string, bool) {
+	if IsRangeSpecifier(spec) || strings.Contains(spec, "-") {
+		return "", false
+	}
+	vendor := Config.Settings.DefaultVendor[Config.Language]
+	if vendor == "" {
+		return "", false
+	}
+	return spec + "-" + vendor, true
+}
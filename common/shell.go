@@ -0,0 +1,47 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ShellVersionVariable returns the name of the environment variable that
+// overrides the version of the language for the current shell.
+func ShellVersionVariable(language string) string {
+	return fmt.Sprintf("LENV_%s_VERSION", strings.ToUpper(language))
+}
+
+// CurrentShell returns the shell that evaluates lenv output, as set by the
+// shell integration function.
+func CurrentShell() string {
+	return os.Getenv("LENV_SHELL")
+}
+
+// ShellSetEnv returns code that sets an environment variable in the given shell.
+func ShellSetEnv(shell string, name string, value string) (string, error) {
+	switch shell {
+	case "bash", "zsh", "sh":
+		return fmt.Sprintf("export %s='%s'", name, strings.ReplaceAll(value, "'", `'\''`)), nil
+	case "fish":
+		return fmt.Sprintf("set -gx %s '%s'", name, strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value)), nil
+	case "pwsh", "powershell":
+		return fmt.Sprintf("$env:%s = '%s'", name, strings.ReplaceAll(value, "'", "''")), nil
+	default:
+		return "", fmt.Errorf("unsupported shell: %s", shell)
+	}
+}
+
+// ShellUnsetEnv returns code that removes an environment variable in the given shell.
+func ShellUnsetEnv(shell string, name string) (string, error) {
+	switch shell {
+	case "bash", "zsh", "sh":
+		return fmt.Sprintf("unset %s", name), nil
+	case "fish":
+		return fmt.Sprintf("set -e %s", name), nil
+	case "pwsh", "powershell":
+		return fmt.Sprintf("Remove-Item Env:%s -ErrorAction SilentlyContinue", name), nil
+	default:
+		return "", fmt.Errorf("unsupported shell: %s", shell)
+	}
+}
@@ -0,0 +1,175 @@
+package common
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const shimMarker = "lenv-shim"
+
+func ShimsDir() string {
+	return filepath.Join(GetRoot(), "shims")
+}
+
+// FindExecutables returns the names of the executables in dir without
+// platform specific extensions.
+func FindExecutables(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if runtime.GOOS == "windows" {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if ext == ".exe" || ext == ".cmd" || ext == ".bat" {
+				names = append(names, strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+			}
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if (info.Mode().IsRegular() && info.Mode()&0111 != 0) || info.Mode()&os.ModeSymlink != 0 {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// FindExecutable looks for the executable name in dirs and returns its full path.
+func FindExecutable(dirs []string, name string) string {
+	candidates := []string{name}
+	if runtime.GOOS == "windows" {
+		candidates = []string{name + ".exe", name + ".cmd", name + ".bat", name}
+	}
+	for _, dir := range dirs {
+		for _, candidate := range candidates {
+			path := filepath.Join(dir, candidate)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+func shimOwner(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for i := 0; i < 3 && scanner.Scan(); i++ {
+		fields := strings.Fields(scanner.Text())
+		for j, field := range fields {
+			if field == shimMarker && j+1 < len(fields) {
+				return fields[j+1]
+			}
+		}
+	}
+	return ""
+}
+
+func shimFileName(name string) string {
+	if runtime.GOOS == "windows" {
+		return name + ".cmd"
+	}
+	return name
+}
+
+func shimContent(language string, name string, lenvPath string) string {
+	if runtime.GOOS == "windows" {
+		return fmt.Sprintf("@echo off\r\nrem %s %s\r\n\"%s\" %s exec %s %%*\r\n", shimMarker, language, lenvPath, language, name)
+	}
+	return fmt.Sprintf("#!/bin/sh\n# %s %s\nexec \"%s\" %s exec \"%s\" \"$@\"\n", shimMarker, language, lenvPath, language, name)
+}
+
+// WriteShims replaces the shims of the language with shims for the given
+// executable names. Shims owned by other languages are left untouched.
+func WriteShims(language string, names []string) error {
+	lenvPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get lenv executable path: %v", err)
+	}
+	shimsDir := ShimsDir()
+	if err := os.MkdirAll(shimsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create shims directory: %v", err)
+	}
+	entries, err := os.ReadDir(shimsDir)
+	if err != nil {
+		return fmt.Errorf("failed to read shims directory: %v", err)
+	}
+	owners := map[string]string{}
+	for _, entry := range entries {
+		path := filepath.Join(shimsDir, entry.Name())
+		owner := shimOwner(path)
+		if owner == language {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove shim: %v", err)
+			}
+			continue
+		}
+		owners[entry.Name()] = owner
+	}
+	for _, name := range names {
+		fileName := shimFileName(name)
+		if owner, ok := owners[fileName]; ok {
+			if owner != "" {
+				Statusf("Skipping %s: shim is provided by %s\n", name, owner)
+			}
+			continue
+		}
+		owners[fileName] = language
+		content := shimContent(language, name, lenvPath)
+		err := os.WriteFile(filepath.Join(shimsDir, fileName), []byte(content), 0755)
+		if err != nil {
+			return fmt.Errorf("failed to write shim: %v", err)
+		}
+	}
+	return nil
+}
+
+// PrependPath returns env with dirs added to the front of PATH.
+func PrependPath(env []string, dirs []string) []string {
+	key := "PATH"
+	for i, entry := range env {
+		name, value, _ := strings.Cut(entry, "=")
+		if sameEnvKey(name, key) {
+			env[i] = name + "=" + strings.Join(dirs, string(os.PathListSeparator)) + string(os.PathListSeparator) + value
+			return env
+		}
+	}
+	return append(env, key+"="+strings.Join(dirs, string(os.PathListSeparator)))
+}
+
+// SetEnv returns env with the variable key set to value.
+func SetEnv(env []string, key string, value string) []string {
+	for i, entry := range env {
+		name, _, _ := strings.Cut(entry, "=")
+		if sameEnvKey(name, key) {
+			env[i] = key + "=" + value
+			return env
+		}
+	}
+	return append(env, key+"="+value)
+}
+
+func sameEnvKey(a string, b string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
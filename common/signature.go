@@ -0,0 +1,205 @@
+package common
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// TrustedKeys are the minisign public keys that sign the checksum manifests
+// of the lenv-java-versions and lenv-python-versions releases. Keys for
+// internal mirrors can be added as *.pub files in KeysDir.
+//
+// The release keys must be added here before a release; until then every
+// manifest is rejected unless a key is added to KeysDir.
+var TrustedKeys = []string{}
+
+// SkipSignatureVerification disables VerifySignature. It is set by
+// "install --insecure-skip-verify".
+var SkipSignatureVerification bool
+
+// PublicKey is a minisign public key.
+type PublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+	// Source describes where the key came from, e.g. the path of its file.
+	Source string
+}
+
+// KeyID returns the key ID as printed by minisign.
+func (k PublicKey) KeyID() string {
+	id := make([]byte, len(k.ID))
+	for i := range k.ID {
+		id[i] = k.ID[len(k.ID)-1-i]
+	}
+	return strings.ToUpper(hex.EncodeToString(id))
+}
+
+// KeysDir returns the directory with extra trusted keys: LENV_KEYS_DIR, or
+// the keys directory in LENV_HOME.
+func KeysDir() string {
+	if dir := os.Getenv("LENV_KEYS_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(GetRoot(), "keys")
+}
+
+// ParsePublicKey reads a public key in the format of minisign, i.e. an
+// optional "untrusted comment:" line followed by the base64 encoded key.
+func ParsePublicKey(data string, source string) (PublicKey, error) {
+	line := ""
+	for _, l := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "untrusted comment:") {
+			continue
+		}
+		line = l
+		break
+	}
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		return PublicKey{}, fmt.Errorf("invalid public key %s: %v", source, err)
+	}
+	if len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != "Ed" {
+		return PublicKey{}, fmt.Errorf("invalid public key %s: not an Ed25519 minisign key", source)
+	}
+	key := PublicKey{Key: ed25519.PublicKey(raw[10:]), Source: source}
+	copy(key.ID[:], raw[2:10])
+	return key, nil
+}
+
+// LoadTrustedKeys returns the built-in keys and the keys found in KeysDir.
+func LoadTrustedKeys() ([]PublicKey, error) {
+	keys := []PublicKey{}
+	for _, data := range TrustedKeys {
+		key, err := ParsePublicKey(data, "built-in")
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	dir := KeysDir()
+	files, err := filepath.Glob(filepath.Join(dir, "*.pub"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys directory: %v", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key: %v", err)
+		}
+		key, err := ParsePublicKey(string(data), file)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// signature is a parsed minisign signature file.
+type signature struct {
+	algorithm       string
+	keyID           [8]byte
+	value           []byte
+	trustedComment  string
+	globalSignature []byte
+}
+
+func parseSignature(data string) (signature, error) {
+	lines := []string{}
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "untrusted comment:") || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return signature{}, fmt.Errorf("invalid signature file")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return signature{}, fmt.Errorf("invalid signature")
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return signature{}, fmt.Errorf("invalid global signature")
+	}
+	sig := signature{
+		algorithm:       string(raw[:2]),
+		value:           raw[10:],
+		trustedComment:  strings.TrimPrefix(lines[2], "trusted comment: "),
+		globalSignature: global,
+	}
+	copy(sig.keyID[:], raw[2:10])
+	return sig, nil
+}
+
+// VerifySignature checks that data was signed by one of the trusted keys.
+// The signature is fetched from signatureURL and uses the format of
+// minisign, either over the data itself ("Ed") or over its BLAKE2b-512
+// digest ("ED"). The trusted comment is verified as well. Without any
+// trusted key, every signature is rejected.
+func VerifySignature(data string, signatureURL string) error {
+	if SkipSignatureVerification {
+		// "install --insecure-skip-verify" already warned about it.
+		return nil
+	}
+	keys, err := LoadTrustedKeys()
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no trusted signing keys, add the key of the release source to %s or use --insecure-skip-verify", KeysDir())
+	}
+	return verifySignature(data, signatureURL, keys)
+}
+
+func verifySignature(data string, signatureURL string, keys []PublicKey) error {
+	text, err := FetchText(signatureURL)
+	if err != nil {
+		return fmt.Errorf("failed to fetch signature: %v", err)
+	}
+	return checkSignature(data, text, keys)
+}
+
+// checkSignature checks data against the minisign signature file text.
+func checkSignature(data string, text string, keys []PublicKey) error {
+	sig, err := parseSignature(text)
+	if err != nil {
+		return err
+	}
+	var key *PublicKey
+	for i := range keys {
+		if keys[i].ID == sig.keyID {
+			key = &keys[i]
+			break
+		}
+	}
+	if key == nil {
+		return fmt.Errorf("signed by an untrusted key %s", PublicKey{ID: sig.keyID}.KeyID())
+	}
+	message := []byte(data)
+	switch sig.algorithm {
+	case "Ed":
+	case "ED":
+		digest := blake2b.Sum512(message)
+		message = digest[:]
+	default:
+		return fmt.Errorf("unsupported signature algorithm: %s", sig.algorithm)
+	}
+	if !ed25519.Verify(key.Key, message, sig.value) {
+		return fmt.Errorf("signature does not match key %s (%s)", key.KeyID(), key.Source)
+	}
+	global := bytes.Join([][]byte{sig.value, []byte(sig.trustedComment)}, nil)
+	if !ed25519.Verify(key.Key, global, sig.globalSignature) {
+		return fmt.Errorf("trusted comment signature does not match key %s (%s)", key.KeyID(), key.Source)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package common
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+type testKey struct {
+	id      [8]byte
+	private ed25519.PrivateKey
+	public  string
+}
+
+func newTestKey(t *testing.T, id byte) testKey {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := testKey{private: private}
+	key.id[0] = id
+	raw := append(append([]byte("Ed"), key.id[:]...), public...)
+	key.public = "untrusted comment: test key\n" + base64.StdEncoding.EncodeToString(raw) + "\n"
+	return key
+}
+
+// sign creates a minisign signature file for data, prehashed for "ED".
+func (k testKey) sign(data string, algorithm string, comment string) string {
+	message := []byte(data)
+	if algorithm == "ED" {
+		digest := blake2b.Sum512(message)
+		message = digest[:]
+	}
+	value := ed25519.Sign(k.private, message)
+	global := ed25519.Sign(k.private, bytes.Join([][]byte{value, []byte(comment)}, nil))
+	raw := append(append([]byte(algorithm), k.id[:]...), value...)
+	return fmt.Sprintf("untrusted comment: signature\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(raw), comment, base64.StdEncoding.EncodeToString(global))
+}
+
+func parseTestKey(t *testing.T, key testKey) PublicKey {
+	t.Helper()
+	parsed, err := ParsePublicKey(key.public, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestCheckSignature(t *testing.T) {
+	trusted := newTestKey(t, 1)
+	other := newTestKey(t, 2)
+	keys := []PublicKey{parseTestKey(t, trusted)}
+	data := "0123abcd  linux-x64-openjdk.zip\n"
+
+	tests := []struct {
+		name      string
+		signature string
+		data      string
+		wantErr   string
+	}{
+		{"legacy", trusted.sign(data, "Ed", "timestamp:1"), data, ""},
+		{"prehashed", trusted.sign(data, "ED", "timestamp:1"), data, ""},
+		{"tampered data", trusted.sign(data, "ED", "timestamp:1"), data + "x", "signature does not match"},
+		{"untrusted key", other.sign(data, "ED", "timestamp:1"), data, "untrusted key"},
+		{"tampered comment", strings.Replace(trusted.sign(data, "ED", "timestamp:1"), "timestamp:1", "timestamp:2", 1), data, "trusted comment"},
+		{"garbage", "not a signature", data, "invalid signature file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSignature(tt.data, tt.signature, keys)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkSignature() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("checkSignature() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadTrustedKeysFromDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("LENV_KEYS_DIR", dir)
+	key := newTestKey(t, 3)
+	if err := os.WriteFile(filepath.Join(dir, "mirror.pub"), []byte(key.public), 0644); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := LoadTrustedKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != len(TrustedKeys)+1 {
+		t.Fatalf("LoadTrustedKeys() returned %d keys, want %d", len(keys), len(TrustedKeys)+1)
+	}
+	if got, want := keys[len(keys)-1].KeyID(), "0000000000000003"; got != want {
+		t.Errorf("KeyID() = %s, want %s", got, want)
+	}
+}
+
+func TestVerifySignatureWithoutKeys(t *testing.T) {
+	builtIn := TrustedKeys
+	TrustedKeys = nil
+	t.Cleanup(func() { TrustedKeys = builtIn })
+	t.Setenv("LENV_KEYS_DIR", t.TempDir())
+	// No request is made: the URL is unreachable.
+	err := VerifySignature("data", "http://127.0.0.1:0/SHA256SUMS.minisig")
+	if err == nil || !strings.Contains(err.Error(), "no trusted signing keys") {
+		t.Fatalf("VerifySignature() = %v, want an error without trusted keys", err)
+	}
+}
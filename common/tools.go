@@ -1,12 +1,20 @@
 package common
 
 import (
+	"archive/tar"
 	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
 )
 
 func GetPlatformPrefix(osName string, arch string) string {
@@ -34,31 +42,185 @@ func GetPlatformPrefix(osName string, arch string) string {
 	return prefix
 }
 
+// DownloadFile downloads url into the downloads directory of the cache and
+// returns the path of the file. An interrupted download is kept as a
+// ".partial" file together with the ETag or Last-Modified date of the
+// response, and the next call for the same url resumes it with a Range
+// request if the server still has the same file. If another lenv process is
+// downloading the same url, a separate copy is downloaded instead of waiting.
 func DownloadFile(url string) (string, error) {
-	resp, err := http.Get(url)
+	dir := downloadsDir()
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", fmt.Errorf("failed to create downloads directory: %v", err)
+	}
+	key := sha256.Sum256([]byte(url))
+	name := fmt.Sprintf("%x-%s", key[:8], ArchiveFileName(url))
+	partialPath := filepath.Join(dir, name+".partial")
+
+	lock, err := tryLockPath(partialPath + ".lock")
+	if err != nil {
+		return "", err
+	}
+	if lock == nil {
+		path, err := tempDownloadPath(dir, name)
+		if err != nil {
+			return "", err
+		}
+		err = downloadTo(url, path, "")
+		if err != nil {
+			os.Remove(path)
+			return "", err
+		}
+		return path, nil
+	}
+	defer lock.Unlock()
+	err = downloadTo(url, partialPath, strings.TrimSuffix(partialPath, ".partial")+".etag")
+	if err != nil {
+		return "", err
+	}
+	// Move the file away while it is locked, so that the next download of
+	// url can't overwrite it.
+	path, err := tempDownloadPath(dir, name)
+	if err != nil {
+		return "", err
+	}
+	err = os.Rename(partialPath, path)
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to save file: %v", err)
+	}
+	lock.Remove()
+	return path, nil
+}
+
+// tempDownloadPath creates an empty file with a unique name in dir.
+func tempDownloadPath(dir string, name string) (string, error) {
+	file, err := os.CreateTemp(dir, "*-"+name)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %v", err)
+	}
+	file.Close()
+	return file.Name(), nil
+}
+
+// downloadTo downloads url into partialPath, resuming it if validatorPath
+// holds the validator of an earlier response. Without validatorPath the
+// download can't be resumed.
+func downloadTo(url string, partialPath string, validatorPath string) error {
+	var offset int64
+	validator := ""
+	if info, err := os.Stat(partialPath); err == nil && validatorPath != "" {
+		if data, err := os.ReadFile(validatorPath); err == nil {
+			offset = info.Size()
+			validator = strings.TrimSpace(string(data))
+		}
+	}
+
+	resp, err := requestDownload(url, offset, validator)
 	if err != nil {
-		return "", fmt.Errorf("failed to download file: %v", err)
+		return err
+	}
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable ||
+		(resp.StatusCode == http.StatusPartialContent && (offset == 0 || contentRangeStart(resp) != offset)) {
+		// The server can't continue the partial file, start over.
+		resp.Body.Close()
+		offset = 0
+		resp, err = requestDownload(url, 0, "")
+		if err != nil {
+			return err
+		}
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("bad status: %s", resp.Status)
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		flags = os.O_WRONLY | os.O_APPEND
+		Statusf("Resuming download at %s\n", FormatSize(offset))
+	case resp.StatusCode == http.StatusOK:
+		offset = 0
+	default:
+		return fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	if validatorPath != "" {
+		os.Remove(validatorPath)
+		if validator := responseValidator(resp); validator != "" {
+			err := os.WriteFile(validatorPath, []byte(validator), 0644)
+			if err != nil {
+				return fmt.Errorf("failed to save download state: %v", err)
+			}
+		}
 	}
 
-	tmpFile, err := os.CreateTemp("", "javaenv-jdk-*.zip")
+	file, err := os.OpenFile(partialPath, flags, 0644)
 	if err != nil {
-		return "", fmt.Errorf("failed to create temp file: %v", err)
+		return fmt.Errorf("failed to create file: %v", err)
+	}
+	total := int64(0)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
 	}
-	defer tmpFile.Close()
+	progress := NewProgress(total, offset)
+	_, err = io.Copy(io.MultiWriter(file, progress), resp.Body)
+	progress.Finish()
+	closeErr := file.Close()
+	if err != nil {
+		if validatorPath == "" {
+			return fmt.Errorf("failed to save file: %v", err)
+		}
+		return fmt.Errorf("failed to save file, run the command again to resume: %v", err)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to save file: %v", closeErr)
+	}
+	if validatorPath != "" {
+		os.Remove(validatorPath)
+	}
+	return nil
+}
 
-	_, err = io.Copy(tmpFile, resp.Body)
+func requestDownload(url string, offset int64, validator string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to save file: %v", err)
+		return nil, fmt.Errorf("failed to download file: %v", err)
+	}
+	if offset > 0 && validator != "" {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", validator)
+	}
+	resp, err := DoRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %v", err)
 	}
+	return resp, nil
+}
+
+// responseValidator returns the value to send in If-Range to resume the
+// response later. Weak ETags can't be used for ranges.
+func responseValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
 
-	return tmpFile.Name(), nil
+// contentRangeStart returns the first byte of a 206 response, or -1.
+func contentRangeStart(resp *http.Response) int64 {
+	var start, end, total int64
+	_, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total)
+	if err != nil {
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/*", &start, &end); err != nil {
+			return -1
+		}
+	}
+	return start
 }
 
+// Unzip extracts a zip archive. Entries are checked like in Untar, Unix file
+// modes and symlinks stored in the archive are restored, and each file is
+// closed as soon as it is written.
 func Unzip(src, dest string) error {
 	r, err := zip.OpenReader(src)
 	if err != nil {
@@ -66,29 +228,435 @@ func Unzip(src, dest string) error {
 	}
 	defer r.Close()
 
+	var total int64
 	for _, f := range r.File {
-		fpath := filepath.Join(dest, f.Name)
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(fpath, os.ModePerm)
-			continue
+		total += int64(f.UncompressedSize64)
+	}
+	progress := NewProgress(total, 0)
+	defer progress.Finish()
+
+	for _, f := range r.File {
+		fpath, err := archivePath(dest, f.Name)
+		if err != nil {
+			return err
 		}
-		if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
-			return fmt.Errorf("failed to create directories: %v", err)
+		mode := zipFileMode(f)
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(fpath, os.ModePerm); err != nil {
+				return fmt.Errorf("failed to create directories: %v", err)
+			}
+		case mode&os.ModeSymlink != 0:
+			err = unzipSymlink(f, dest, fpath)
+		default:
+			err = unzipFile(f, fpath, mode.Perm(), progress)
 		}
-		rc, err := f.Open()
 		if err != nil {
-			return fmt.Errorf("failed to open file in zip: %v", err)
+			return err
 		}
-		defer rc.Close()
-		outFile, err := os.Create(fpath)
+	}
+	return checkExtractedLinks(dest)
+}
+
+// zipFileMode returns the mode of a zip entry. Entries of archives that were
+// not created on Unix carry no permissions, they are made executable so that
+// binaries work on Unix.
+func zipFileMode(f *zip.File) os.FileMode {
+	const creatorUnix, creatorMacOS = 3, 19
+	creator := f.CreatorVersion >> 8
+	if creator == creatorUnix || creator == creatorMacOS {
+		return f.Mode()
+	}
+	if f.FileInfo().IsDir() {
+		return os.ModeDir | 0755
+	}
+	return 0755
+}
+
+func unzipFile(f *zip.File, fpath string, mode os.FileMode, progress *Progress) error {
+	if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directories: %v", err)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open file in zip: %v", err)
+	}
+	defer rc.Close()
+	outFile, err := os.OpenFile(fpath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create file on disk: %v", err)
+	}
+	_, err = io.Copy(io.MultiWriter(outFile, progress), rc)
+	closeErr := outFile.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write file to disk: %v", err)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to write file to disk: %v", closeErr)
+	}
+	return nil
+}
+
+// unzipSymlink creates a symlink entry, whose content is the link target.
+func unzipSymlink(f *zip.File, dest string, fpath string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open file in zip: %v", err)
+	}
+	target, err := io.ReadAll(io.LimitReader(rc, 4096))
+	rc.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read symlink in zip: %v", err)
+	}
+	if err := checkLinkTarget(dest, fpath, string(target)); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directories: %v", err)
+	}
+	if err := os.Symlink(string(target), fpath); err != nil {
+		return fmt.Errorf("failed to create symlink: %v", err)
+	}
+	return nil
+}
+
+// Archive formats recognized by DetectArchiveFormat.
+const (
+	FormatZip    = "zip"
+	FormatTarGz  = "tar.gz"
+	FormatTarXz  = "tar.xz"
+	FormatTarZst = "tar.zst"
+)
+
+var archiveMagic = []struct {
+	format string
+	magic  []byte
+}{
+	{FormatZip, []byte("PK\x03\x04")},
+	{FormatZip, []byte("PK\x05\x06")},
+	{FormatTarGz, []byte{0x1f, 0x8b}},
+	{FormatTarXz, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{FormatTarZst, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// DetectArchiveFormat tells the format of the archive at path by its first
+// bytes, so archives are recognized whatever they are named.
+func DetectArchiveFormat(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %v", err)
+	}
+	defer file.Close()
+	header := make([]byte, 8)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("failed to read archive: %v", err)
+	}
+	for _, m := range archiveMagic {
+		if bytes.HasPrefix(header[:n], m.magic) {
+			return m.format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported archive format")
+}
+
+// Untar extracts a tar archive compressed with gzip, xz or zstd. File modes,
+// directories, symlinks and hard links are restored; entries that would end
+// up outside dest are rejected.
+func Untar(src, dest string) error {
+	format, err := DetectArchiveFormat(src)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open tar file: %v", err)
+	}
+	defer file.Close()
+	var total int64
+	if info, err := file.Stat(); err == nil {
+		total = info.Size()
+	}
+	progress := NewProgress(total, 0)
+	defer progress.Finish()
+	in := io.TeeReader(file, progress)
+
+	var stream io.Reader
+	switch format {
+	case FormatTarGz:
+		gz, err := gzip.NewReader(in)
 		if err != nil {
-			return fmt.Errorf("failed to create file on disk: %v", err)
+			return fmt.Errorf("failed to open gzip stream: %v", err)
 		}
-		defer outFile.Close()
-		_, err = io.Copy(outFile, rc)
+		defer gz.Close()
+		stream = gz
+	case FormatTarXz:
+		xzReader, err := xz.NewReader(in)
 		if err != nil {
-			return fmt.Errorf("failed to write file to disk: %v", err)
+			return fmt.Errorf("failed to open xz stream: %v", err)
+		}
+		stream = xzReader
+	case FormatTarZst:
+		zr, err := zstd.NewReader(in)
+		if err != nil {
+			return fmt.Errorf("failed to open zstd stream: %v", err)
+		}
+		defer zr.Close()
+		stream = zr
+	default:
+		return fmt.Errorf("not a tar archive: %s", format)
+	}
+	return untar(stream, dest)
+}
+
+func untar(stream io.Reader, dest string) error {
+	r := tar.NewReader(stream)
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar file: %v", err)
+		}
+		fpath, err := archivePath(dest, header.Name)
+		if err != nil {
+			return err
+		}
+		mode := os.FileMode(header.Mode).Perm()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(fpath, os.ModePerm); err != nil {
+				return fmt.Errorf("failed to create directories: %v", err)
+			}
+			if mode != 0 {
+				_ = os.Chmod(fpath, mode|0700)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+				return fmt.Errorf("failed to create directories: %v", err)
+			}
+			outFile, err := os.OpenFile(fpath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+			if err != nil {
+				return fmt.Errorf("failed to create file on disk: %v", err)
+			}
+			_, err = io.Copy(outFile, r)
+			outFile.Close()
+			if err != nil {
+				return fmt.Errorf("failed to write file to disk: %v", err)
+			}
+		case tar.TypeSymlink:
+			if err := checkLinkTarget(dest, fpath, header.Linkname); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+				return fmt.Errorf("failed to create directories: %v", err)
+			}
+			if err := os.Symlink(header.Linkname, fpath); err != nil {
+				return fmt.Errorf("failed to create symlink: %v", err)
+			}
+		case tar.TypeLink:
+			target, err := archivePath(dest, header.Linkname)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+				return fmt.Errorf("failed to create directories: %v", err)
+			}
+			if err := os.Link(target, fpath); err != nil {
+				return fmt.Errorf("failed to create hard link: %v", err)
+			}
 		}
 	}
+	return checkExtractedLinks(dest)
+}
+
+// archivePath returns where the archive entry name goes in dest, rejecting
+// absolute names, names that climb out of dest with ".." and names that lead
+// out of dest through symlinks extracted before.
+func archivePath(dest string, name string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "/") || strings.HasPrefix(name, `\`) {
+		return "", fmt.Errorf("illegal file path in archive: %s", name)
+	}
+	fpath := filepath.Join(dest, name)
+	if !isInside(dest, fpath) {
+		return "", fmt.Errorf("illegal file path in archive: %s", name)
+	}
+	inside, err := resolvesInside(dest, fpath)
+	if err != nil {
+		return "", err
+	}
+	if !inside {
+		return "", fmt.Errorf("illegal file path in archive: %s leads outside through a symlink", name)
+	}
+	return fpath, nil
+}
+
+// checkLinkTarget rejects symlinks that point outside dest, since later
+// entries could be written through them. The target is resolved with the
+// symlinks that were already extracted.
+func checkLinkTarget(dest string, link string, target string) error {
+	if filepath.IsAbs(target) || strings.HasPrefix(target, "/") || strings.HasPrefix(target, `\`) {
+		return fmt.Errorf("illegal symlink in archive: %s -> %s", link, target)
+	}
+	if !isInside(dest, filepath.Join(filepath.Dir(link), target)) {
+		return fmt.Errorf("illegal symlink in archive: %s -> %s", link, target)
+	}
+	// Not joined, which would remove ".." before the symlinks are resolved.
+	inside, err := resolvesInside(dest, filepath.Dir(link)+string(os.PathSeparator)+target)
+	if err != nil {
+		return err
+	}
+	if !inside {
+		return fmt.Errorf("illegal symlink in archive: %s -> %s", link, target)
+	}
 	return nil
 }
+
+// checkExtractedLinks walks dest after an archive was extracted and rejects
+// symlinks that point outside of it. Links are checked when they are
+// created, but a later entry can change what an earlier link resolves to.
+func checkExtractedLinks(dest string) error {
+	return filepath.WalkDir(dest, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type()&os.ModeSymlink == 0 {
+			return nil
+		}
+		inside, err := resolvesInside(dest, path)
+		if err != nil {
+			return err
+		}
+		if !inside {
+			target, _ := os.Readlink(path)
+			return fmt.Errorf("illegal symlink in archive: %s -> %s", path, target)
+		}
+		return nil
+	})
+}
+
+// isInside reports whether the clean path is dest or below it.
+func isInside(dest string, path string) bool {
+	dest = filepath.Clean(dest)
+	return path == dest || strings.HasPrefix(path, dest+string(os.PathSeparator))
+}
+
+// resolvesInside reports whether path stays inside dest once the symlinks
+// on the way are resolved.
+func resolvesInside(dest string, path string) (bool, error) {
+	realDest, err := resolvePath(dest)
+	if err != nil {
+		return false, err
+	}
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return false, err
+	}
+	return isInside(realDest, resolved), nil
+}
+
+// resolvePath is like filepath.EvalSymlinks, but also works for paths that
+// don't exist (yet): missing components are taken as they are. ".." is
+// applied to the resolved path, as the file system does.
+func resolvePath(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		// Not filepath.Abs, which would clean the path.
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		path = cwd + string(os.PathSeparator) + path
+	}
+	volume := filepath.VolumeName(path)
+	resolved := volume + string(os.PathSeparator)
+	rest := path[len(volume):]
+	links := 0
+	for rest != "" {
+		var name string
+		name, rest = cutPathComponent(rest)
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+		next := filepath.Join(resolved, name)
+		target, err := os.Readlink(next)
+		if err != nil {
+			// Not a symlink, or missing.
+			resolved = next
+			continue
+		}
+		links++
+		if links > 255 {
+			return "", fmt.Errorf("too many levels of symlinks in %s", path)
+		}
+		if v := filepath.VolumeName(target); v != "" || filepath.IsAbs(target) || strings.HasPrefix(target, "/") {
+			resolved = v + string(os.PathSeparator)
+			target = target[len(v):]
+		}
+		rest = target + string(os.PathSeparator) + rest
+	}
+	return resolved, nil
+}
+
+// cutPathComponent splits the first component off path.
+func cutPathComponent(path string) (string, string) {
+	for i := 0; i < len(path); i++ {
+		if os.IsPathSeparator(path[i]) {
+			return path[:i], path[i+1:]
+		}
+	}
+	return path, ""
+}
+
+// Extract unpacks the archive src into dest. The format is detected from
+// the content, see DetectArchiveFormat. With stripTopLevel, an archive that
+// holds a single top-level directory has its contents moved up into dest.
+func Extract(src, dest string, stripTopLevel bool) error {
+	format, err := DetectArchiveFormat(src)
+	if err != nil {
+		return err
+	}
+	if format == FormatZip {
+		err = Unzip(src, dest)
+	} else {
+		err = Untar(src, dest)
+	}
+	if err != nil {
+		return err
+	}
+	if !stripTopLevel {
+		return nil
+	}
+	return stripTopLevelDir(dest)
+}
+
+func stripTopLevelDir(dest string) error {
+	entries, err := os.ReadDir(dest)
+	if err != nil {
+		return fmt.Errorf("failed to read directory: %v", err)
+	}
+	if len(entries) != 1 || !entries[0].IsDir() {
+		return nil
+	}
+	topDir := filepath.Join(dest, entries[0].Name())
+	children, err := os.ReadDir(topDir)
+	if err != nil {
+		return fmt.Errorf("failed to read directory: %v", err)
+	}
+	for _, child := range children {
+		if child.Name() == entries[0].Name() {
+			return nil
+		}
+	}
+	for _, child := range children {
+		err := os.Rename(filepath.Join(topDir, child.Name()), filepath.Join(dest, child.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to move %s: %v", child.Name(), err)
+		}
+	}
+	return os.Remove(topDir)
+}
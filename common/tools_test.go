@@ -0,0 +1,297 @@
+package common
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	body     string
+	mode     int64
+}
+
+func writeTarGz(t *testing.T, entries []tarEntry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "archive.tar.gz")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		mode := e.mode
+		if mode == 0 {
+			mode = 0644
+		}
+		header := &tar.Header{Name: e.name, Typeflag: e.typeflag, Linkname: e.linkname, Mode: mode, Size: int64(len(e.body))}
+		if e.typeflag != tar.TypeReg {
+			header.Size = 0
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if e.typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(e.body)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// extractDest returns a destination directory inside a parent directory, so
+// tests can check that nothing was written next to it.
+func extractDest(t *testing.T) (string, string) {
+	t.Helper()
+	parent := t.TempDir()
+	dest := filepath.Join(parent, "dest")
+	if err := os.Mkdir(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return parent, dest
+}
+
+func assertNoEscape(t *testing.T, parent string) {
+	t.Helper()
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "dest" {
+			t.Errorf("%s was written outside of dest", entry.Name())
+		}
+	}
+}
+
+func TestUntarRejectsTraversal(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tarEntry
+	}{
+		{"parent directory", []tarEntry{
+			{name: "../escaped.txt", typeflag: tar.TypeReg, body: "x"},
+		}},
+		{"nested parent directory", []tarEntry{
+			{name: "jdk/../../escaped.txt", typeflag: tar.TypeReg, body: "x"},
+		}},
+		{"absolute name", []tarEntry{
+			{name: "/tmp/escaped.txt", typeflag: tar.TypeReg, body: "x"},
+		}},
+		{"symlink to parent", []tarEntry{
+			{name: "link", typeflag: tar.TypeSymlink, linkname: ".."},
+			{name: "link/escaped.txt", typeflag: tar.TypeReg, body: "x"},
+		}},
+		{"absolute symlink", []tarEntry{
+			{name: "link", typeflag: tar.TypeSymlink, linkname: "/etc"},
+		}},
+		{"symlink chain", []tarEntry{
+			{name: "y", typeflag: tar.TypeSymlink, linkname: "."},
+			{name: "z", typeflag: tar.TypeSymlink, linkname: "y/.."},
+			{name: "z/escaped.txt", typeflag: tar.TypeReg, body: "x"},
+		}},
+		{"symlink changed by a later entry", []tarEntry{
+			{name: "a/b/", typeflag: tar.TypeDir, mode: 0755},
+			{name: "a/b/link", typeflag: tar.TypeSymlink, linkname: "m/../../x"},
+			{name: "a/b/m", typeflag: tar.TypeSymlink, linkname: "../.."},
+		}},
+		{"hard link to parent", []tarEntry{
+			{name: "link", typeflag: tar.TypeLink, linkname: "../outside.txt"},
+		}},
+		{"hard link through symlink", []tarEntry{
+			{name: "y", typeflag: tar.TypeSymlink, linkname: "."},
+			{name: "link", typeflag: tar.TypeLink, linkname: "y/../outside.txt"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := writeTarGz(t, tt.entries)
+			parent, dest := extractDest(t)
+			if err := Untar(archive, dest); err == nil {
+				t.Fatal("Untar() succeeded, want an error")
+			}
+			assertNoEscape(t, parent)
+		})
+	}
+}
+
+func TestUntarKeepsLinksAndModes(t *testing.T) {
+	archive := writeTarGz(t, []tarEntry{
+		{name: "jdk/", typeflag: tar.TypeDir, mode: 0755},
+		{name: "jdk/bin/java", typeflag: tar.TypeReg, body: "#!/bin/sh\n", mode: 0755},
+		{name: "jdk/lib/libjvm.so", typeflag: tar.TypeReg, body: "elf", mode: 0644},
+		{name: "jdk/lib/server", typeflag: tar.TypeSymlink, linkname: "."},
+		{name: "jdk/bin/java-link", typeflag: tar.TypeLink, linkname: "jdk/bin/java"},
+		{name: "jdk/legal/LICENSE", typeflag: tar.TypeSymlink, linkname: "../lib/server/libjvm.so"},
+	})
+	_, dest := extractDest(t)
+	if err := Untar(archive, dest); err != nil {
+		t.Fatalf("Untar() = %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dest, "jdk/bin/java"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("mode of java = %v, want 0755", info.Mode().Perm())
+	}
+	data, err := os.ReadFile(filepath.Join(dest, "jdk/legal/LICENSE"))
+	if err != nil || string(data) != "elf" {
+		t.Errorf("reading through symlinks = %q, %v", data, err)
+	}
+	linked, err := os.Stat(filepath.Join(dest, "jdk/bin/java-link"))
+	if err != nil || !os.SameFile(info, linked) {
+		t.Errorf("hard link is not the same file: %v", err)
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	dir := t.TempDir()
+	real, err := resolvePath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(".", filepath.Join(dir, "y")); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"y":              real,
+		"y/y/missing":    filepath.Join(real, "missing"),
+		"y/..":           filepath.Dir(real),
+		"missing/../a/b": filepath.Join(real, "a/b"),
+	}
+	for path, want := range tests {
+		got, err := resolvePath(dir + string(os.PathSeparator) + filepath.FromSlash(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("resolvePath(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestArchivePathRejectsBackslashRoot(t *testing.T) {
+	_, err := archivePath(t.TempDir(), `\windows\system32`)
+	if err == nil || !strings.Contains(err.Error(), "illegal file path") {
+		t.Fatalf("archivePath() = %v, want illegal file path", err)
+	}
+}
+
+type zipEntry struct {
+	name string
+	body string
+	mode os.FileMode
+}
+
+func writeZip(t *testing.T, entries []zipEntry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "archive.zip")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zw := zip.NewWriter(file)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		mode := e.mode
+		if mode == 0 {
+			mode = 0644
+		}
+		header.SetMode(mode)
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUnzipRejectsTraversal(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []zipEntry
+	}{
+		{"parent directory", []zipEntry{
+			{name: "../escaped.txt", body: "x"},
+		}},
+		{"absolute name", []zipEntry{
+			{name: "/tmp/escaped.txt", body: "x"},
+		}},
+		{"backslash root", []zipEntry{
+			{name: `\escaped.txt`, body: "x"},
+		}},
+		{"symlink to parent", []zipEntry{
+			{name: "link", body: "..", mode: os.ModeSymlink | 0777},
+			{name: "link/escaped.txt", body: "x"},
+		}},
+		{"absolute symlink", []zipEntry{
+			{name: "link", body: "/etc", mode: os.ModeSymlink | 0777},
+		}},
+		{"symlink chain", []zipEntry{
+			{name: "y", body: ".", mode: os.ModeSymlink | 0777},
+			{name: "z", body: "y/..", mode: os.ModeSymlink | 0777},
+			{name: "z/escaped.txt", body: "x"},
+		}},
+		{"symlink changed by a later entry", []zipEntry{
+			{name: "a/b/link", body: "m/../../x", mode: os.ModeSymlink | 0777},
+			{name: "a/b/m", body: "../..", mode: os.ModeSymlink | 0777},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := writeZip(t, tt.entries)
+			parent, dest := extractDest(t)
+			if err := Unzip(archive, dest); err == nil {
+				t.Fatal("Unzip() succeeded, want an error")
+			}
+			assertNoEscape(t, parent)
+		})
+	}
+}
+
+func TestUnzipKeepsLinksAndModes(t *testing.T) {
+	archive := writeZip(t, []zipEntry{
+		{name: "jdk/bin/java", body: "#!/bin/sh\n", mode: 0755},
+		{name: "jdk/lib/libjvm.so", body: "elf"},
+		{name: "jdk/legal/LICENSE", body: "../lib/libjvm.so", mode: os.ModeSymlink | 0777},
+	})
+	_, dest := extractDest(t)
+	if err := Unzip(archive, dest); err != nil {
+		t.Fatalf("Unzip() = %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dest, "jdk/bin/java"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("mode of java = %v, want 0755", info.Mode().Perm())
+	}
+	data, err := os.ReadFile(filepath.Join(dest, "jdk/legal/LICENSE"))
+	if err != nil || string(data) != "elf" {
+		t.Errorf("reading through symlink = %q, %v", data, err)
+	}
+}
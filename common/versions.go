@@ -2,19 +2,33 @@ package common
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+
+	ver "github.com/hashicorp/go-version"
 )
 
 type Version struct {
-	Version string `json:"version"`
-	Path    string `json:"path"`
-	Vendor  string `json:"vendor"`
+	Version     string `json:"version"`
+	Path        string `json:"path"`
+	Vendor      string `json:"vendor"`
+	RequiresJDK string `json:"requires_jdk,omitempty"`
 }
 
 func (v Version) Name() string {
+	if v.Vendor == "" {
+		return v.Version
+	}
 	return fmt.Sprintf("%s-%s", v.Version, v.Vendor)
 }
 
+// ParseVersionName splits a version name such as "11-openjdk" into the
+// version and the vendor. The vendor is empty for names without one.
+func ParseVersionName(name string) (string, string) {
+	version, vendor, _ := strings.Cut(name, "-")
+	return version, vendor
+}
+
 type ServerVersion struct {
 	TagName string  `json:"tag_name"`
 	Assets  []Asset `json:"assets"`
@@ -33,6 +47,15 @@ func FindVersion(versions []Version, targetVersion string, targetVendor string)
 	return nil
 }
 
+func FindVersionByName(versions []Version, name string) *Version {
+	for _, v := range versions {
+		if v.Name() == name {
+			return &v
+		}
+	}
+	return nil
+}
+
 func ParseAssetName(assetName string) string {
 	parts := strings.Split(assetName, "-")
 	if len(parts) < 2 {
@@ -41,3 +64,141 @@ func ParseAssetName(assetName string) string {
 	nameWithoutSuffix := strings.TrimSuffix(parts[1], ".zip")
 	return nameWithoutSuffix
 }
+
+// IsRangeSpecifier reports whether spec is a version range such as ">=3.11,<3.13".
+func IsRangeSpecifier(spec string) bool {
+	return strings.ContainsAny(spec, "<>=!~,")
+}
+
+// ResolveSpecifier returns the version in versions that best matches spec.
+// An exact name always wins. Otherwise spec may be "latest", a partial
+// version such as "17" or "3.12", or a range such as ">=3.11,<3.13"; the
+// first two may carry a vendor suffix, e.g. "17-openjdk". The highest
+// matching version is chosen and pre-releases are only considered when spec
+// names one explicitly. If nothing matches, nil is returned without an error.
+func ResolveSpecifier(versions []Version, spec string) (*Version, error) {
+	if exact := FindVersionByName(versions, spec); exact != nil {
+		return exact, nil
+	}
+
+	var match func(v *ver.Version) bool
+	vendor := ""
+	if IsRangeSpecifier(spec) {
+		constraints, err := ver.NewConstraint(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version range %s: %v", spec, err)
+		}
+		match = constraints.Check
+	} else {
+		var prefix string
+		prefix, vendor = ParseVersionName(spec)
+		if prefix == "latest" {
+			match = func(v *ver.Version) bool {
+				return v.Prerelease() == ""
+			}
+		} else {
+			wanted, err := ver.NewVersion(prefix)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version %s: %v", spec, err)
+			}
+			count := len(strings.Split(prefix, "."))
+			match = func(v *ver.Version) bool {
+				if v.Prerelease() != wanted.Prerelease() {
+					return false
+				}
+				segments := v.Segments()
+				for i, segment := range wanted.Segments()[:count] {
+					if i >= len(segments) || segments[i] != segment {
+						return false
+					}
+				}
+				return true
+			}
+		}
+	}
+
+	var best *Version
+	var bestVersion *ver.Version
+	for i, v := range versions {
+		if vendor != "" && v.Vendor != vendor {
+			continue
+		}
+		parsed, err := ver.NewVersion(v.Version)
+		if err != nil || !match(parsed) {
+			continue
+		}
+		if bestVersion == nil || parsed.GreaterThan(bestVersion) {
+			best = &versions[i]
+			bestVersion = parsed
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+	result := *best
+	return &result, nil
+}
+
+// LineGrouper is implemented by languages whose release lines are not simply
+// the major version, e.g. Python's "3.12".
+type LineGrouper interface {
+	VersionLine(version Version) string
+}
+
+// VersionLine returns the release line that a version belongs to, which is
+// used to group and filter lists.
+func VersionLine(language Language, version Version) string {
+	if grouper, ok := language.(LineGrouper); ok {
+		return grouper.VersionLine(version)
+	}
+	fields := strings.FieldsFunc(version.Version, func(r rune) bool {
+		return r == '.' || r == '_' || r == '+' || r == '-'
+	})
+	if len(fields) == 0 {
+		return version.Version
+	}
+	return fields[0]
+}
+
+// MinorLine returns the "major.minor" release line of a version, for
+// languages that group by minor version.
+func MinorLine(version Version) string {
+	v, err := ver.NewVersion(version.Version)
+	if err != nil {
+		return version.Version
+	}
+	segments := v.Segments()
+	return fmt.Sprintf("%d.%d", segments[0], segments[1])
+}
+
+// compareVersionStrings compares two version strings semantically. Strings
+// that cannot be parsed sort before parsable ones and by name among themselves.
+func compareVersionStrings(a string, b string) int {
+	va, errA := ver.NewVersion(a)
+	vb, errB := ver.NewVersion(b)
+	switch {
+	case errA == nil && errB == nil:
+		return va.Compare(vb)
+	case errA == nil:
+		return 1
+	case errB == nil:
+		return -1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// SortVersions sorts versions newest release line first, then by vendor and
+// then newest version first, so that versions of a line and vendor are adjacent.
+func SortVersions(language Language, versions []Version) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, b := versions[i], versions[j]
+		if c := compareVersionStrings(VersionLine(language, a), VersionLine(language, b)); c != 0 {
+			return c > 0
+		}
+		if a.Vendor != b.Vendor {
+			return a.Vendor < b.Vendor
+		}
+		return compareVersionStrings(a.Version, b.Version) > 0
+	})
+}
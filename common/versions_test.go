@@ -0,0 +1,55 @@
+package common
+
+import "testing"
+
+func TestResolveSpecifier(t *testing.T) {
+	versions := []Version{
+		{Version: "11.0.2", Vendor: "openjdk"},
+		{Version: "17.0.2", Vendor: "openjdk"},
+		{Version: "17.0.9", Vendor: "temurin"},
+		{Version: "17", Vendor: "zulu"},
+		{Version: "21.0.1", Vendor: "temurin"},
+		{Version: "22.0.0-rc1", Vendor: "temurin"},
+		{Version: "3.12.4"},
+		{Version: "3.13.0"},
+	}
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"17-zulu", "17-zulu"},
+		{"17", "17.0.9-temurin"},
+		{"17-openjdk", "17.0.2-openjdk"},
+		{"17.0", "17.0.9-temurin"},
+		{"latest", "21.0.1-temurin"},
+		{"latest-openjdk", "17.0.2-openjdk"},
+		{"22", ""},
+		{">=3.11,<3.13", "3.12.4"},
+		{"3.12", "3.12.4"},
+		{"18", ""},
+		{"17-corretto", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ResolveSpecifier(versions, tt.spec)
+			if err != nil {
+				t.Fatalf("ResolveSpecifier(%s) = %v", tt.spec, err)
+			}
+			name := ""
+			if got != nil {
+				name = got.Name()
+			}
+			if name != tt.want {
+				t.Errorf("ResolveSpecifier(%s) = %q, want %q", tt.spec, name, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveSpecifierInvalid(t *testing.T) {
+	for _, spec := range []string{">=x", "abc"} {
+		if _, err := ResolveSpecifier([]Version{{Version: "1.0"}}, spec); err == nil {
+			t.Errorf("ResolveSpecifier(%s) succeeded, want an error", spec)
+		}
+	}
+}
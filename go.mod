@@ -2,10 +2,16 @@ module kiber-io/lenv
 
 go 1.23.2
 
-require github.com/spf13/cobra v1.8.1
+require (
+	github.com/hashicorp/go-version v1.7.0
+	github.com/klauspost/compress v1.18.0
+	github.com/spf13/cobra v1.8.1
+	github.com/ulikunitz/xz v0.5.15
+	golang.org/x/crypto v0.41.0
+	golang.org/x/sys v0.35.0
+)
 
 require (
-	github.com/hashicorp/go-version v1.7.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 )
@@ -59,6 +59,7 @@ get_latest_asset() {
 
 new_directories() {
   mkdir -p "$lenv_home_path/bin"
+  mkdir -p "$lenv_home_path/shims"
   if [ ! -e "$lenv_home_path/java/current" ] && [ ! -L "$lenv_home_path/java/current" ]; then
     mkdir -p "$lenv_home_path/java/current"
   fi
@@ -97,16 +98,39 @@ update_environment_variables() {
   if ! grep -q "export PATH=\$LENV_HOME/bin:\$JAVA_HOME/bin:\$PATH" "$profile_file"; then
     echo "export PATH=\$LENV_HOME/bin:\$JAVA_HOME/bin:\$PATH" >> "$profile_file"
   fi
+  if ! grep -q "export PATH=\$LENV_HOME/shims:\$PATH" "$profile_file"; then
+    echo "export PATH=\$LENV_HOME/shims:\$PATH" >> "$profile_file"
+  fi
 
   ensure_newline "$bashrc_file"
   if ! grep -q "export LENV_HOME=$lenv_home_path" "$bashrc_file"; then
     echo "export LENV_HOME=$lenv_home_path" >> "$bashrc_file"
   fi
-  if ! grep -q "export PATH=\$LENV_HOME/bin:\$JAVA_HOME/bin:\$PATH" "$bashrc_file"; then
-    echo "export PATH=\$LENV_HOME/bin:\$JAVA_HOME/bin:\$PATH" >> "$bashrc_file"
+  if ! grep -q "lenv init bash" "$bashrc_file"; then
+    echo "eval \"\$(\$LENV_HOME/bin/lenv init bash)\"" >> "$bashrc_file"
+  fi
+
+  zshrc_file="$HOME/.zshrc"
+  if [ -f "$zshrc_file" ]; then
+    ensure_newline "$zshrc_file"
+    if ! grep -q "export LENV_HOME=$lenv_home_path" "$zshrc_file"; then
+      echo "export LENV_HOME=$lenv_home_path" >> "$zshrc_file"
+    fi
+    if ! grep -q "lenv init zsh" "$zshrc_file"; then
+      echo "eval \"\$(\$LENV_HOME/bin/lenv init zsh)\"" >> "$zshrc_file"
+    fi
   fi
-  if ! grep -q "export PATH=\$LENV_HOME/python/current/bin:\$PATH" "$bashrc_file"; then
-    echo "export PATH=\$LENV_HOME/python/current/bin:\$PATH" >> "$bashrc_file"
+
+  fish_config_file="$HOME/.config/fish/config.fish"
+  if [ -d "$(dirname "$fish_config_file")" ]; then
+    touch "$fish_config_file"
+    ensure_newline "$fish_config_file"
+    if ! grep -q "set -gx LENV_HOME $lenv_home_path" "$fish_config_file"; then
+      echo "set -gx LENV_HOME $lenv_home_path" >> "$fish_config_file"
+    fi
+    if ! grep -q "lenv init fish" "$fish_config_file"; then
+      echo "\$LENV_HOME/bin/lenv init fish | source" >> "$fish_config_file"
+    fi
   fi
 }
 
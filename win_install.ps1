@@ -10,6 +10,7 @@ function Initialize-EnvironmentVariables {
         ENV_PATH        = "Path"
         lenvHomePath    = $lenvHomePath
         lenvHomeBinPath = "$lenvHomePath\bin"
+        lenvShimsPath   = "$lenvHomePath\shims"
         javaCurrentPath = "$lenvHomePath\java\current"
         pythonCurrentPath = "$lenvHomePath\python\current"
     }
@@ -50,6 +51,9 @@ function New-Directories ($envVars) {
     if (!(Test-Path -Path $envVars.lenvHomeBinPath)) {
         New-Item -ItemType Directory -Path $envVars.lenvHomeBinPath | Out-Null
     }
+    if (!(Test-Path -Path $envVars.lenvShimsPath)) {
+        New-Item -ItemType Directory -Path $envVars.lenvShimsPath | Out-Null
+    }
     if (!(Test-Path -Path $envVars.javaCurrentPath)) {
         New-Item -ItemType Directory -Path $envVars.javaCurrentPath | Out-Null
     }
@@ -85,18 +89,33 @@ function Update-EnvironmentVariables ($envVars) {
     if (-not $path.Contains($envVars.pythonCurrentPath)) {
         $path = "%$($envVars.ENV_LENV_HOME)%\python\current;%$($envVars.ENV_LENV_HOME)%\python\current\Scripts;$path"
     }
+    if (-not $path.Contains("%$($envVars.ENV_LENV_HOME)%\shims")) {
+        $path = "%$($envVars.ENV_LENV_HOME)%\shims;$path"
+    }
 
     [System.Environment]::SetEnvironmentVariable($envVars.ENV_LENV_HOME, $envVars.lenvHomePath, [System.EnvironmentVariableTarget]::User)
     [System.Environment]::SetEnvironmentVariable($envVars.ENV_JAVA_HOME, "%$($envVars.ENV_LENV_HOME)%\java\current", [System.EnvironmentVariableTarget]::User)
     [System.Environment]::SetEnvironmentVariable($envVars.ENV_PATH, $path, [System.EnvironmentVariableTarget]::User)
 }
 
+function Add-ShellIntegration {
+    $profileDir = Split-Path -Parent $PROFILE
+    if (!(Test-Path -Path $profileDir)) {
+        New-Item -ItemType Directory -Path $profileDir | Out-Null
+    }
+    if ((Test-Path -Path $PROFILE) -and (Select-String -Path $PROFILE -Pattern 'lenv.exe" init pwsh' -SimpleMatch -Quiet)) {
+        return
+    }
+    Add-Content -Path $PROFILE -Value '& "$env:LENV_HOME\bin\lenv.exe" init pwsh | Out-String | Invoke-Expression'
+}
+
 function Main {
     Test-Admin
     $envVars = Initialize-EnvironmentVariables
     New-Directories $envVars
     Get-Asset $envVars
     Update-EnvironmentVariables $envVars
+    Add-ShellIntegration
     Write-Output "Installation completed. Please restart your terminal to start using lenv."
 }
 